@@ -8,7 +8,7 @@ mkdir -pv ./out/coverage
 
 go fmt ./...
 
-go test -coverprofile=./out/coverage/coverage.out
+go test -coverprofile=./out/coverage/coverage.out ./...
 go tool cover -func=./out/coverage/coverage.out
 go tool cover -html=./out/coverage/coverage.out -o ./out/coverage/coverage.html
 
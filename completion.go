@@ -0,0 +1,205 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Shells supported by WriteCompletion.
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// A completion describes how a command-line flag is completed.
+type completion struct {
+	name   string
+	desc   string
+	values []string
+	file   bool
+	bare   bool
+}
+
+// nonIdentifier matches characters not allowed in shell function names.
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// WriteCompletion writes a completion script for the given shell ("bash",
+// "zsh" or "fish") to w. The script completes flag names, the values
+// accepted by options limited by OneOf and the built-in flags, and file
+// paths for the JSON configuration file named by JSONKey.
+func (l MultiLoader) WriteCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case ShellBash:
+		script = l.bashCompletion()
+	case ShellZsh:
+		script = l.zshCompletion()
+	case ShellFish:
+		script = l.fishCompletion()
+	default:
+		return fmt.Errorf("unknown shell: %s", shell)
+	}
+
+	if _, err := io.WriteString(w, script); err != nil {
+		return fmt.Errorf("error writing %s completion: %w", shell, err)
+	}
+
+	return nil
+}
+
+// completions returns how each command-line flag is completed, with the
+// options in sorted order followed by the built-in flags. The flag named by
+// CompletionKey is hidden.
+func (l MultiLoader) completions() []completion {
+	options := l.options()
+
+	var completions []completion
+	for _, name := range sortedKeys(options) {
+		option := options[name]
+		desc := option.Desc
+		if desc == "" {
+			desc = name
+		}
+		completions = append(completions, completion{name: name, desc: desc, values: option.enum})
+	}
+
+	if l.JSONKey != "" {
+		completions = append(completions, completion{name: l.JSONKey, desc: "JSON configuration file", file: true})
+	}
+	for _, flag := range l.sourceFlags() {
+		if flag.name != "" {
+			completions = append(completions, completion{name: flag.name, desc: flag.desc})
+		}
+	}
+	if l.GenerateConfigKey != "" {
+		completions = append(completions, completion{
+			name:   l.GenerateConfigKey,
+			desc:   "print a sample configuration file and exit",
+			values: []string{FormatJSON, FormatEnv},
+		})
+	}
+	if l.PrintConfigKey != "" {
+		completions = append(completions, completion{
+			name:   l.PrintConfigKey,
+			desc:   "print the loaded configuration and exit",
+			values: []string{FormatTable, FormatJSON, FormatEnv},
+		})
+	}
+	completions = append(completions, completion{name: "help", desc: "show help and exit", bare: true})
+
+	return completions
+}
+
+// completionFunc returns the name of the shell function for completion.
+func (l MultiLoader) completionFunc() string {
+	return "_" + nonIdentifier.ReplaceAllString(l.name(), "_") + "_completion"
+}
+
+// bashCompletion returns a bash completion script.
+func (l MultiLoader) bashCompletion() string {
+	var b strings.Builder
+	fn := l.completionFunc()
+
+	var flags []string
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", l.name())
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, c := range l.completions() {
+		flags = append(flags, "-"+c.name)
+		switch {
+		case c.file:
+			fmt.Fprintf(&b, "        %s|%s)\n", shellQuote("-"+c.name), shellQuote("--"+c.name))
+			b.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n            return\n            ;;\n")
+		case len(c.values) > 0:
+			fmt.Fprintf(&b, "        %s|%s)\n", shellQuote("-"+c.name), shellQuote("--"+c.name))
+			fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n            return\n            ;;\n",
+				shellQuote(strings.Join(c.values, " ")))
+		}
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(flags, " ")))
+	b.WriteString("    fi\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, shellQuote(l.name()))
+
+	return b.String()
+}
+
+// zshCompletion returns a zsh completion script.
+func (l MultiLoader) zshCompletion() string {
+	var b strings.Builder
+	fn := l.completionFunc()
+
+	fmt.Fprintf(&b, "#compdef %s\n\n", l.name())
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("  _arguments")
+	for _, c := range l.completions() {
+		spec := "-" + c.name + "[" + zshEscape(c.desc) + "]"
+		switch {
+		case c.bare:
+		case c.file:
+			spec += ":" + zshEscape(c.name) + ":_files"
+		case len(c.values) > 0:
+			values := make([]string, len(c.values))
+			for i, value := range c.values {
+				values[i] = strings.NewReplacer(" ", `\ `, "(", `\(`, ")", `\)`).Replace(zshEscape(value))
+			}
+			spec += ":" + zshEscape(c.name) + ":(" + strings.Join(values, " ") + ")"
+		default:
+			spec += ":" + zshEscape(c.name) + ": "
+		}
+		fmt.Fprintf(&b, " \\\n    %s", shellQuote(spec))
+	}
+	b.WriteString("\n}\n\n")
+	fmt.Fprintf(&b, "if [ \"$funcstack[1]\" = %s ]; then\n", shellQuote(fn))
+	fmt.Fprintf(&b, "  %s \"$@\"\n", fn)
+	b.WriteString("else\n")
+	fmt.Fprintf(&b, "  compdef %s %s\n", fn, shellQuote(l.name()))
+	b.WriteString("fi\n")
+
+	return b.String()
+}
+
+// fishCompletion returns a fish completion script.
+func (l MultiLoader) fishCompletion() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# fish completion for %s\n\n", l.name())
+	for _, c := range l.completions() {
+		fmt.Fprintf(&b, "complete -c %s -o %s -d %s", fishQuote(l.name()), fishQuote(c.name), fishQuote(c.desc))
+		switch {
+		case c.bare:
+		case c.file:
+			b.WriteString(" -r -F")
+		case len(c.values) > 0:
+			fmt.Fprintf(&b, " -x -a %s", fishQuote(strings.Join(c.values, " ")))
+		default:
+			b.WriteString(" -x")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// shellQuote quotes a string for bash and zsh using single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a string for fish using single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// zshEscape escapes the characters with special meaning in a zsh
+// _arguments specification.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
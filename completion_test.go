@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func completionLoader() *MultiLoader {
+	return &MultiLoader{
+		Name: "my-app",
+		Options: map[string]Option{
+			"mode": Option{Desc: "run mode"}.OneOf("dev", "prod"),
+			"port": Option{},
+		},
+		JSONKey:       "conf",
+		CompletionKey: "completion",
+	}
+}
+
+func TestWriteCompletionBash(t *testing.T) {
+	var out strings.Builder
+	if err := completionLoader().WriteCompletion(&out, ShellBash); err != nil {
+		t.Fatalf("Unexpected error writing bash completion: %s", err)
+	}
+
+	expected := `# bash completion for my-app
+
+_my_app_completion() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+        '-mode'|'--mode')
+            COMPREPLY=($(compgen -W 'dev prod' -- "$cur"))
+            return
+            ;;
+        '-conf'|'--conf')
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+    esac
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W '-mode -port -conf -help' -- "$cur"))
+    fi
+}
+
+complete -o default -F _my_app_completion 'my-app'
+`
+	if out.String() != expected {
+		t.Error("Bash completion doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestWriteCompletionZsh(t *testing.T) {
+	var out strings.Builder
+	if err := completionLoader().WriteCompletion(&out, ShellZsh); err != nil {
+		t.Fatalf("Unexpected error writing zsh completion: %s", err)
+	}
+
+	expected := `#compdef my-app
+
+_my_app_completion() {
+  _arguments \
+    '-mode[run mode]:mode:(dev prod)' \
+    '-port[port]:port: ' \
+    '-conf[JSON configuration file]:conf:_files' \
+    '-help[show help and exit]'
+}
+
+if [ "$funcstack[1]" = '_my_app_completion' ]; then
+  _my_app_completion "$@"
+else
+  compdef _my_app_completion 'my-app'
+fi
+`
+	if out.String() != expected {
+		t.Error("Zsh completion doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestWriteCompletionFish(t *testing.T) {
+	var out strings.Builder
+	if err := completionLoader().WriteCompletion(&out, ShellFish); err != nil {
+		t.Fatalf("Unexpected error writing fish completion: %s", err)
+	}
+
+	expected := `# fish completion for my-app
+
+complete -c 'my-app' -o 'mode' -d 'run mode' -x -a 'dev prod'
+complete -c 'my-app' -o 'port' -d 'port' -x
+complete -c 'my-app' -o 'conf' -d 'JSON configuration file' -r -F
+complete -c 'my-app' -o 'help' -d 'show help and exit'
+`
+	if out.String() != expected {
+		t.Error("Fish completion doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestWriteCompletionUnknownShellError(t *testing.T) {
+	var out strings.Builder
+	err := completionLoader().WriteCompletion(&out, "tcsh")
+	if expectedMsg := "unknown shell: tcsh"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown shell")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestLoadWithCompletionFlag(t *testing.T) {
+	var out strings.Builder
+	loader := completionLoader()
+	loader.Output = &out
+
+	config, origin, err := loader.load([]string{"-completion", "fish"}, sampleFlagsHandler)
+	if !errors.Is(err, ErrExit) {
+		t.Errorf("Expected exit request on printing completion, got: %v", err)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected values on printing completion")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+
+	if expected := "complete -c 'my-app' -o 'mode'"; !strings.Contains(out.String(), expected) {
+		t.Error("Printed completion doesn't match")
+		t.Errorf("\nActual       : %q", out.String())
+		t.Errorf("\nExpected part: %q", expected)
+	}
+}
+
+func TestCompletionFlagIsHiddenInHelp(t *testing.T) {
+	var out strings.Builder
+	if err := completionLoader().WriteHelp(&out, "my-app"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	if strings.Contains(out.String(), "-completion") {
+		t.Errorf("Unexpected completion flag in help:\n%s", out.String())
+	}
+}
@@ -46,9 +46,14 @@
 package conf
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -60,6 +65,15 @@ type Loader interface {
 	Load() (config map[string]string, origin map[string]string, err error)
 }
 
+// A ContextLoader is a Loader that can be bounded by a context.
+type ContextLoader interface {
+	Loader
+
+	// LoadContext extracts configuration as Load does, giving up when ctx
+	// is cancelled or its deadline passes.
+	LoadContext(ctx context.Context) (config map[string]string, origin map[string]string, err error)
+}
+
 // An Option represents a configuration for github.com/chiku/conf.
 type Option struct {
 	// Default is the value used if not provided in command-line flag,
@@ -71,6 +85,24 @@ type Option struct {
 
 	// Mandatory is true if the configuration must be specified.
 	Mandatory bool
+
+	// Secret is true if the configuration value must not be shown, such
+	// as when printed by WriteConfig.
+	Secret bool
+
+	// Deprecated, if not empty, explains why the configuration should no
+	// longer be used. A warning is reported when the configuration is set
+	// by anything other than Default.
+	Deprecated string
+
+	// Validate, if not nil, checks a non-empty configuration value after
+	// all sources are merged. The returned error is reported against the
+	// configuration key. OneOf, Range, URL and Pattern set Validate.
+	Validate func(value string) error
+
+	enum    []string
+	kind    string
+	pattern string
 }
 
 // MultiLoader is a configuration loader with different sources.
@@ -81,42 +113,233 @@ type MultiLoader struct {
 	// configuration and origin returned by Load() use the same keys.
 	Options map[string]Option
 
+	// Groups are named sets of options, whose configuration keys are
+	// prefixed by the group name. The configuration and origin returned
+	// by Load() contain the keys of Groups along with those of Options.
+	Groups []Group
+
 	// JSONKey, if not empty, is the configuration key name expected
-	// for the JSON configuration file.
+	// for the JSON configuration file. The flag may be repeated to layer
+	// several files, with later files overriding earlier ones key by key.
 	JSONKey string
 
+	// JSONEnv, if not empty, is the environment variable holding the JSON
+	// configuration file when no file is given with the JSONKey flag.
+	// Several files are separated by the OS path list separator, such as
+	// colon (:) on Unix, with later files overriding earlier ones.
+	JSONEnv string
+
+	// JSONDefault, if not empty, is the JSON configuration file used when
+	// no file is given with the JSONKey flag or JSONEnv. Several files are
+	// separated as in JSONEnv.
+	JSONDefault string
+
+	// SearchFile, if not empty, is the name of a JSON configuration file
+	// looked up in SearchPaths when no file is given with the JSONKey
+	// flag, JSONEnv or JSONDefault. The first file found is loaded.
+	SearchFile string
+
+	// SearchPaths are the directories searched for SearchFile, in order.
+	// They default to the working directory, the application directory
+	// in the user configuration directory, such as
+	// $XDG_CONFIG_HOME/<Name>, and /etc/<Name>.
+	SearchPaths []string
+
+	// RequireFile is true if a JSON configuration file must be given with
+	// the JSONKey flag, JSONEnv or JSONDefault, or found through
+	// SearchFile.
+	RequireFile bool
+
+	// UnknownFileKeys decides how keys in JSON configuration files that
+	// are not configuration keys are handled, such as a misspelled "prot"
+	// for "port". It defaults to IgnoreUnknown.
+	UnknownFileKeys Strictness
+
+	// Sources provide configuration values from elsewhere, such as an
+	// HTTPSource. They are consulted after the JSON files and before
+	// environment variables, with earlier Sources overriding later ones.
+	Sources []Source
+
+	// Decrypter, if not nil, decrypts configuration values of the form
+	// "enc:<base64>" from any source, so that credentials can be kept
+	// encrypted in JSON files. See AESGCM.
+	Decrypter Decrypter
+
+	// EnvPrefix, if not empty, is the prefix of the environment variables
+	// of the application, such as "MYAPP_". The environment variable of
+	// each configuration key is then EnvPrefix followed by the key in
+	// upper case with dots (.) and dashes (-) replaced by underscores (_),
+	// such as MYAPP_DB_HOST for "db.host". Configuration keys sharing an
+	// environment variable, such as "db.host" and "db-host", are rejected.
+	EnvPrefix string
+
+	// UnknownEnv decides how environment variables starting with
+	// EnvPrefix that do not belong to a configuration key are handled,
+	// such as a misspelled MYAPP_DB_HSOT. It defaults to IgnoreUnknown.
+	UnknownEnv Strictness
+
+	// Environ, if not nil, replaces os.Environ as the source of
+	// environment variables, as a list of "name=value" strings.
+	Environ func() []string
+
+	// Warn, if not nil, receives warnings such as unknown keys reported
+	// under WarnUnknown and deprecated options in use. Otherwise, warnings
+	// are printed to os.Stderr unless Logger is present.
+	Warn func(warning string)
+
+	// Logger, if not nil, receives events while loading: the JSON files
+	// selected and how they were selected, as reported by ConfigFiles, each
+	// source consulted, each value overriding another, each configuration
+	// resolved with its origin, and warnings. Values of Secret options and
+	// encrypted values are redacted.
+	Logger *slog.Logger
+
+	// Name is the name of the application, used in completion scripts
+	// and default SearchPaths. It defaults to the base name of the
+	// running program.
+	Name string
+
 	// Usage is a description for the application. Usage shows up when
 	// the application is run with "-help".
 	Usage string
+
+	// HelpTemplate, if not empty, is a text/template that renders help
+	// when the application is run with "-help". It is executed with
+	// HelpData, and can use the functions "wrap", that indents and wraps
+	// text to the terminal width as in {{wrap 4 .Desc}}, and "join".
+	HelpTemplate string
+
+	// Constraints are relations between configuration keys, such as one
+	// key requiring another. All violated Constraints are reported together.
+	Constraints []Constraint
+
+	// GenerateConfigKey, if not empty, is the command-line flag that
+	// prints a sample configuration file in the format given as its value
+	// ("json" or "env") to Output and exits.
+	GenerateConfigKey string
+
+	// PrintConfigKey, if not empty, is the command-line flag that prints
+	// the loaded configuration and origin in the format given as its value
+	// ("table", "json" or "env") to Output and exits. Secret values and
+	// values decrypted by Decrypter are redacted. The configuration is
+	// printed before mandatory configurations, Validate and Constraints are
+	// checked, and Load returns their error, if any, after printing.
+	PrintConfigKey string
+
+	// CompletionKey, if not empty, is the hidden command-line flag that
+	// prints a completion script for the shell given as its value ("bash",
+	// "zsh" or "fish") to Output and exits. The flag is not shown in help.
+	CompletionKey string
+
+	// Output is where built-in flags such as "-help" print. It defaults
+	// to os.Stdout.
+	Output io.Writer
+
+	registrations []*Registration
 }
 
+// ErrExit is returned by LoadArgs when a built-in flag such as "-help" has
+// printed its output and the application should exit without running.
+var ErrExit = errors.New("exit requested")
+
+// Origins of configuration values returned by Load().
+const (
+	// OriginFlags is the origin of values from command-line flags.
+	OriginFlags = "Flags"
+
+	// OriginJSON is followed by the file name in the origin of values from
+	// JSON files, as in "JSON:<file>".
+	OriginJSON = "JSON:"
+
+	// OriginEnvironment is the origin of values from environment variables.
+	OriginEnvironment = "Environment"
+
+	// OriginDefaults is the origin of values from Option.Default.
+	OriginDefaults = "Defaults"
+
+	// OriginSearch is how a JSON configuration file found through
+	// SearchFile is selected, as reported by ConfigFiles. Files selected
+	// by the JSONKey flag, JSONEnv or JSONDefault are reported as
+	// OriginFlags, OriginEnvironment or OriginDefaults.
+	OriginSearch = "Search"
+)
+
 // Load extracts configuration from different sources. It returns the
 // configuration and their origin, and an error if present.
 // The configurations are loaded in following order.
 //  1. Command-line arguments
-//  2. JSON file mentioned in JSONKey
-//  3. Environment variable
-//  4. Default values.
+//  2. JSON files mentioned in JSONKey, from the last to the first, or else
+//     in JSONEnv or JSONDefault, or else the file found through SearchFile
+//  3. Sources, from the first to the last
+//  4. Environment variable
+//  5. Default values.
 //
-// The origin is returned as a string and can be one of "Flags", "JSON",
+// The origin is returned as a string and can be one of "Flags",
+// "JSON:<file>", the origin reported by a Source such as "HTTP:<url>",
 // "Environment" or "Defaults"
 // based on what was matched when looking up for the configuration.
 // The configuration is always returned as a map[string]string.
 // Load() returns an error in the following cases.
 //  1. Command-line argument parse fails.
-//  2. JSON parse fails.
-//  3. Mandatory configuration was not provided.
+//  2. JSON parse fails, or RequireFile is true and no JSON file is found,
+//     or a JSON file has unknown keys under RejectUnknown.
+//  3. There are unknown environment variables under RejectUnknown.
+//  4. A Source fails to fetch its values.
+//  5. An encrypted configuration value cannot be decrypted by Decrypter.
+//  6. Mandatory configuration was not provided.
+//  7. A configuration value is rejected by the Validate of its Option.
+//  8. One or more Constraints are violated.
 func (l MultiLoader) Load() (config map[string]string, origin map[string]string, err error) {
+	return l.LoadContext(context.Background())
+}
+
+// LoadContext extracts configuration as Load does. Sources are fetched
+// with ctx, and loading stops when ctx is cancelled or its deadline passes.
+// The error then names the source being read, such as "JSON:<file>" or
+// "HTTP:<url>", and whether it timed out.
+func (l MultiLoader) LoadContext(ctx context.Context) (config map[string]string, origin map[string]string, err error) {
 	program, args := os.Args[0], os.Args[1:]
 	flagsHandler := func(flags *flag.FlagSet) {
 		flags.Usage = func() {
-			fmt.Printf("%s: %s\n\nParameters:\n", program, l.Usage)
-			flags.PrintDefaults()
+			if err := l.WriteHelp(l.output(), program); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", program, err)
+				os.Exit(2)
+			}
 			os.Exit(0)
 		}
 	}
 
-	return l.load(args, flagsHandler)
+	config, origin, err = l.loadContext(ctx, args, flagsHandler)
+	if errors.Is(err, ErrExit) {
+		os.Exit(0)
+	}
+
+	return config, origin, err
+}
+
+// LoadArgs extracts configuration as LoadContext does, from the given
+// command-line arguments without the program name, and without exiting the
+// program. It returns ErrExit when a built-in flag such as "-help" has
+// printed its output. Help and errors parsing flags are printed to Output.
+func (l MultiLoader) LoadArgs(
+	ctx context.Context,
+	args []string,
+) (config map[string]string, origin map[string]string, err error) {
+	var helpErr error
+	flagsHandler := func(flags *flag.FlagSet) {
+		flags.SetOutput(l.output())
+		flags.Usage = func() { helpErr = l.WriteHelp(l.output(), l.name()) }
+	}
+
+	config, origin, err = l.loadContext(ctx, args, flagsHandler)
+	if errors.Is(err, flag.ErrHelp) {
+		if helpErr != nil {
+			return nil, nil, fmt.Errorf("conf.Load: %w", helpErr)
+		}
+		return nil, nil, ErrExit
+	}
+
+	return config, origin, err
 }
 
 // load extracts configuration from different sources. It returns the
@@ -124,51 +347,151 @@ func (l MultiLoader) Load() (config map[string]string, origin map[string]string,
 func (l MultiLoader) load(
 	args []string,
 	flagsHandler func(flags *flag.FlagSet),
+) (config map[string]string, origin map[string]string, err error) {
+	return l.loadContext(context.Background(), args, flagsHandler)
+}
+
+// loadContext extracts configuration from different sources as load does,
+// stopping when ctx is done.
+func (l MultiLoader) loadContext(
+	ctx context.Context,
+	args []string,
+	flagsHandler func(flags *flag.FlagSet),
 ) (config map[string]string, origin map[string]string, err error) {
 	if err := l.validate(); err != nil {
 		return nil, nil, fmt.Errorf("conf.Load: %w", err)
 	}
 
-	flagVals, err := l.parseFlags(args, flagsHandler)
+	flagVals, jsonFiles, err := l.parseFlags(args, flagsHandler)
 	if err != nil {
 		return nil, nil, fmt.Errorf("conf.Load: %w", err)
 	}
 
-	jsonFile := flagVals[l.JSONKey]
-	jsonConfig, err := parseJSON(jsonFile)
+	if format := flagVals[l.GenerateConfigKey]; format != nil && *format != "" {
+		if err := l.GenerateConfig(l.output(), *format); err != nil {
+			return nil, nil, fmt.Errorf("conf.Load: %w", err)
+		}
+		return nil, nil, ErrExit
+	}
+
+	if shell := flagVals[l.CompletionKey]; shell != nil && *shell != "" {
+		if err := l.WriteCompletion(l.output(), *shell); err != nil {
+			return nil, nil, fmt.Errorf("conf.Load: %w", err)
+		}
+		return nil, nil, ErrExit
+	}
+
+	jsonFiles, selectedBy, err := l.configFiles(jsonFiles)
 	if err != nil {
 		return nil, nil, fmt.Errorf("conf.Load: %w", err)
 	}
+	l.logFiles(ctx, jsonFiles, selectedBy)
 
+	if err := l.reportUnknown(l.UnknownEnv, "environment variables", l.unknownEnv()); err != nil {
+		return nil, nil, fmt.Errorf("conf.Load: %w", err)
+	}
+
+	jsonConfigs, sourceValues, sourceOrigins, err := l.fetchAll(ctx, jsonFiles, flagVals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("conf.Load: %w", err)
+	}
+
+	unknown := l.unknownFileKeys(jsonFiles, jsonConfigs)
+	if err := l.reportUnknown(l.UnknownFileKeys, "keys in JSON files", unknown); err != nil {
+		return nil, nil, fmt.Errorf("conf.Load: %w", err)
+	}
+
+	options := l.options()
 	config = make(map[string]string)
 	origin = make(map[string]string)
 
-	l.configure(config, origin, func(key string) string { return *flagVals[key] }, "Flags")
-	l.configure(config, origin, func(key string) string { return jsonConfig[key] }, "JSON")
-	l.configure(config, origin, os.Getenv, "Environment")
-	l.configure(config, origin, func(key string) string { return l.Options[key].Default }, "Defaults")
+	l.configure(ctx, config, origin, func(key string) string { return *flagVals[key] }, OriginFlags)
+	for i := len(jsonFiles) - 1; i >= 0; i-- {
+		jsonConfig := jsonConfigs[i]
+		l.configure(ctx, config, origin, func(key string) string { return jsonConfig[key] }, OriginJSON+jsonFiles[i])
+	}
+	for i, source := range l.Sources {
+		l.configureSource(ctx, config, origin, sourceName(source), sourceValues[i], sourceOrigins[i])
+	}
+	l.configure(ctx, config, origin, func(key string) string { return l.getenv(l.envName(key)) }, OriginEnvironment)
+	l.configure(ctx, config, origin, func(key string) string { return options[key].Default }, OriginDefaults)
 
-	if err = l.verifyMandatoryPresent(config); err != nil {
+	encrypted, err := l.decrypt(config, origin)
+	if err != nil {
 		return nil, nil, fmt.Errorf("conf.Load: %w", err)
 	}
 
+	// The configuration is printed before it is verified, so that a
+	// configuration that fails to load can be inspected.
+	if format := flagVals[l.PrintConfigKey]; format != nil && *format != "" {
+		if err := l.writeConfig(l.output(), config, origin, *format, encrypted); err != nil {
+			return nil, nil, fmt.Errorf("conf.Load: %w", err)
+		}
+		if err := l.verify(config, origin); err != nil {
+			return nil, nil, fmt.Errorf("conf.Load: %w", err)
+		}
+		return nil, nil, ErrExit
+	}
+
+	if err := l.verify(config, origin); err != nil {
+		return nil, nil, fmt.Errorf("conf.Load: %w", err)
+	}
+
+	l.warnDeprecated(config, origin)
+	l.logResolved(ctx, config, origin, encrypted)
+
+	for _, registration := range l.registrations {
+		registration.fill(config, origin)
+	}
+
 	return config, origin, nil
 }
 
-// validate checks that Options keys do not contain equals (=) and do not start
-// with minus (-). If JSONKey is present, it validates does not contain
-// equals (=) and does not start with minus (-).
+// validate checks that Groups are valid and that configuration keys do not
+// contain equals (=) and do not start with minus (-). If built-in flag keys
+// such as JSONKey are present, it validates they do not contain equals (=),
+// do not start with minus (-) and do not clash with configuration keys or
+// each other. It also checks that configuration keys do not share an
+// environment variable, file discovery is possible, HelpTemplate parses and
+// Constraints refer only to configuration keys.
 func (l MultiLoader) validate() error {
-	if jsonKey := l.JSONKey; strings.Contains(l.JSONKey, "=") {
-		return fmt.Errorf("JSONKey cannot contain '=': %s", jsonKey)
+	if err := l.validateDeclarations(); err != nil {
+		return err
+	}
+
+	options := l.options()
+	builtins := []struct{ field, name string }{
+		{"JSONKey", l.JSONKey},
+		{"GenerateConfigKey", l.GenerateConfigKey},
+		{"PrintConfigKey", l.PrintConfigKey},
+		{"CompletionKey", l.CompletionKey},
 	}
-	if jsonKey := l.JSONKey; strings.HasPrefix(l.JSONKey, "-") {
-		return fmt.Errorf("JSONKey cannot start with '-': %s", jsonKey)
+	for _, flag := range l.sourceFlags() {
+		builtins = append(builtins, struct{ field, name string }{flag.field, flag.name})
+	}
+	seen := make(map[string]string)
+	for _, builtin := range builtins {
+		if builtin.name == "" {
+			continue
+		}
+		if strings.Contains(builtin.name, "=") {
+			return fmt.Errorf("%s cannot contain '=': %s", builtin.field, builtin.name)
+		}
+		if strings.HasPrefix(builtin.name, "-") {
+			return fmt.Errorf("%s cannot start with '-': %s", builtin.field, builtin.name)
+		}
+		if _, ok := options[builtin.name]; ok {
+			return fmt.Errorf("%s is also an option: %s", builtin.field, builtin.name)
+		}
+		if other, ok := seen[builtin.name]; ok {
+			return fmt.Errorf("%s is the same as %s: %s", builtin.field, other, builtin.name)
+		}
+		seen[builtin.name] = builtin.field
 	}
 
 	var optionsWithEquals []string
 	var optionsStartingWithMinus []string
-	for name := range l.Options {
+	for name := range options {
 		if strings.Contains(name, "=") {
 			optionsWithEquals = append(optionsWithEquals, name)
 		}
@@ -185,22 +508,38 @@ func (l MultiLoader) validate() error {
 		return fmt.Errorf("options cannot start with '-': %s", strings.Join(optionsStartingWithMinus, ", "))
 	}
 
-	return nil
+	if err := l.validateEnvNames(); err != nil {
+		return err
+	}
+
+	if err := l.validateDiscovery(); err != nil {
+		return err
+	}
+
+	if l.UnknownEnv != IgnoreUnknown && l.EnvPrefix == "" {
+		return errors.New("UnknownEnv needs EnvPrefix")
+	}
+
+	if _, err := l.helpTemplate(); err != nil {
+		return err
+	}
+
+	return l.validateConstraints()
 }
 
 // parseFlags parses application-level command-line flags. The flags
 // are based on the configuration value and JSON-key flag. It returns
-// the parsed values as a map of string to pointer of strings and an
-// error if parse fails.
+// the parsed values as a map of string to pointer of strings, the JSON
+// files in the order given and an error if parse fails.
 func (l MultiLoader) parseFlags(
 	args []string,
 	flagsHandler func(*flag.FlagSet),
-) (flagVals map[string]*string, err error) {
+) (flagVals map[string]*string, jsonFiles []string, err error) {
 	flags := flag.NewFlagSet("", flag.ContinueOnError)
 	flagsHandler(flags)
 
 	flagVals = make(map[string]*string)
-	for name, option := range l.Options {
+	for name, option := range l.options() {
 		if desc := option.Desc; desc != "" {
 			flagVals[name] = flags.String(name, "", desc)
 		} else {
@@ -208,16 +547,55 @@ func (l MultiLoader) parseFlags(
 		}
 	}
 
+	var files fileList
 	if l.JSONKey != "" {
-		flagVals[l.JSONKey] = flags.String(l.JSONKey, "", "JSON configuration file")
+		flags.Var(&files, l.JSONKey, "JSON configuration file, repeated to override earlier files")
+	}
+
+	if l.GenerateConfigKey != "" {
+		flagVals[l.GenerateConfigKey] = flags.String(l.GenerateConfigKey, "",
+			"print a sample configuration file in the given format (json, env) and exit")
+	}
+
+	if l.PrintConfigKey != "" {
+		flagVals[l.PrintConfigKey] = flags.String(l.PrintConfigKey, "",
+			"print the loaded configuration in the given format (table, json, env) and exit")
+	}
+
+	if l.CompletionKey != "" {
+		flagVals[l.CompletionKey] = flags.String(l.CompletionKey, "",
+			"print a completion script for the given shell (bash, zsh, fish) and exit")
+	}
+
+	for _, flag := range l.sourceFlags() {
+		if flag.name != "" {
+			flagVals[flag.name] = flags.String(flag.name, "", flag.desc)
+		}
 	}
 
 	err = flags.Parse(args)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing flags: %w", err)
+		return nil, nil, fmt.Errorf("error parsing flags: %w", err)
+	}
+
+	return flagVals, files, nil
+}
+
+// name returns Name, or the base name of the running program if Name is
+// empty.
+func (l MultiLoader) name() string {
+	if l.Name == "" {
+		return filepath.Base(os.Args[0])
 	}
+	return l.Name
+}
 
-	return flagVals, nil
+// output returns Output, or os.Stdout if Output is nil.
+func (l MultiLoader) output() io.Writer {
+	if l.Output == nil {
+		return os.Stdout
+	}
+	return l.Output
 }
 
 // A mappingFunc on running returns a value against a key. MappingFuncs
@@ -225,13 +603,43 @@ func (l MultiLoader) parseFlags(
 type mappingFunc func(key string) (value string)
 
 // Configure adds value and origin against a key if not already present.
-func (l MultiLoader) configure(config map[string]string, origin map[string]string, mapping mappingFunc, from string) {
-	for name := range l.Options {
+// It logs the number of values found and the values they override, if
+// other than defaults.
+func (l MultiLoader) configure(
+	ctx context.Context,
+	config map[string]string,
+	origin map[string]string,
+	mapping mappingFunc,
+	from string,
+) {
+	found := 0
+	for _, name := range sortedKeys(l.options()) {
+		value := mapping(name)
+		if value != "" {
+			found++
+		}
 		if config[name] == "" {
-			config[name] = mapping(name)
+			config[name] = value
 			origin[name] = from
+		} else if value != "" && from != OriginDefaults {
+			l.logOverride(ctx, name, origin[name], from)
 		}
 	}
+	l.logSource(ctx, from, found)
+}
+
+// verify returns an error if mandatory configurations are missing, a value
+// is rejected by the Validate of its Option or Constraints are violated.
+func (l MultiLoader) verify(config map[string]string, origin map[string]string) error {
+	if err := l.verifyMandatoryPresent(config); err != nil {
+		return err
+	}
+
+	if err := l.verifyValid(config, origin); err != nil {
+		return err
+	}
+
+	return l.verifyConstraints(config)
 }
 
 // VerifyMandatoryPresent returns an error if one or more mandatory
@@ -239,7 +647,7 @@ func (l MultiLoader) configure(config map[string]string, origin map[string]strin
 // configuration keys.
 func (l MultiLoader) verifyMandatoryPresent(config map[string]string) error {
 	var missing []string
-	for name, option := range l.Options {
+	for name, option := range l.options() {
 		if config[name] == "" && option.Mandatory {
 			missing = append(missing, name)
 		}
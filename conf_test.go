@@ -1,6 +1,8 @@
 package conf
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -83,7 +85,7 @@ func TestLoadFromJSON(t *testing.T) {
 		t.Errorf("\nExpected: %#v", expectedConfig)
 	}
 
-	expectedOrigin := map[string]string{"man": jsonOrig, "opt": jsonOrig}
+	expectedOrigin := map[string]string{"man": jsonOrig + ":" + jsonFile, "opt": jsonOrig + ":" + jsonFile}
 	if !reflect.DeepEqual(origin, expectedOrigin) {
 		t.Error("Origins don't match when loaded from JSON file")
 		t.Errorf("\nActual  : %#v", origin)
@@ -91,6 +93,52 @@ func TestLoadFromJSON(t *testing.T) {
 	}
 }
 
+func TestLoadFromLayeredJSONFiles(t *testing.T) {
+	baseFile := createFile(t, `{ "man": "man:base", "opt": "opt:base", "opt2": "opt2:base" }`)
+	overlayFile := createFile(t, `{ "man": "man:overlay", "opt": "opt:overlay" }`)
+	localFile := createFile(t, `{ "man": "man:local" }`)
+	defer func() {
+		for _, file := range []string{baseFile, overlayFile, localFile} {
+			if err := os.Remove(file); err != nil {
+				t.Fatalf("Unexpected error deleting temporary file: %s", err)
+			}
+		}
+	}()
+
+	options := map[string]Option{
+		"man":  Option{Mandatory: true},
+		"opt":  Option{},
+		"opt2": Option{},
+		"opt3": Option{Default: optd},
+	}
+	loader := &MultiLoader{Options: options, JSONKey: "conf"}
+
+	args := []string{"-conf", baseFile, "-conf", overlayFile, "-conf", localFile}
+	config, origin, err := loader.load(args, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from layered JSON files: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:local", "opt": "opt:overlay", "opt2": "opt2:base", "opt3": optd}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from layered JSON files")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{
+		"man":  jsonOrig + ":" + localFile,
+		"opt":  jsonOrig + ":" + overlayFile,
+		"opt2": jsonOrig + ":" + baseFile,
+		"opt3": defaultsOrig,
+	}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from layered JSON files")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
 func TestLoadFromEnvironment(t *testing.T) {
 	t.Setenv("man", mane)
 	t.Setenv("opt", opte)
@@ -216,7 +264,7 @@ func TestLoadFromJSONHasPriorityOverEnvironmentAndDefaults(t *testing.T) {
 		t.Errorf("\nExpected: %#v", expectedConfig)
 	}
 
-	expectedOrigin := map[string]string{"man": jsonOrig, "opt": jsonOrig}
+	expectedOrigin := map[string]string{"man": jsonOrig + ":" + jsonFile, "opt": jsonOrig + ":" + jsonFile}
 	if !reflect.DeepEqual(origin, expectedOrigin) {
 		t.Error("Origins don't match when loaded from JSON, environment variable and defaults")
 		t.Errorf("\nActual  : %#v", origin)
@@ -359,7 +407,7 @@ func TestJSONFileReadError(t *testing.T) {
 	loader := &MultiLoader{Options: options, JSONKey: "conf"}
 
 	config, origin, err := loader.load([]string{"-conf", "file-does-not-exist"}, sampleFlagsHandler)
-	if expectedMsg := "conf.Load: error reading JSON file: "; !strings.Contains(err.Error(), expectedMsg) {
+	if expectedMsg := "conf.Load: error reading JSON:file-does-not-exist: open file-does-not-exist: "; !strings.Contains(err.Error(), expectedMsg) {
 		t.Error("Invalid error message for missing JSON file")
 		t.Errorf("Actual       : %q", err)
 		t.Errorf("Expected part: %q", expectedMsg)
@@ -388,7 +436,8 @@ func TestJSONFileParseError(t *testing.T) {
 	loader := &MultiLoader{Options: options, JSONKey: "conf"}
 
 	config, origin, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
-	if expectedMsg := "conf.Load: json: "; !strings.Contains(err.Error(), expectedMsg) {
+	expectedMsg := "conf.Load: error reading JSON:" + jsonFile + ": json: syntax error at offset 1: invalid character 'b' looking for beginning of value"
+	if err == nil || err.Error() != expectedMsg {
 		t.Error("Invalid error message for malformed JSON file")
 		t.Errorf("Actual       : %q", err)
 		t.Errorf("Expected part: %q", expectedMsg)
@@ -464,6 +513,24 @@ func TestFuzzError1(t *testing.T) {
 	}
 }
 
+func TestLoadArgsWithHelpExits(t *testing.T) {
+	var out strings.Builder
+	loader := &MultiLoader{Options: map[string]Option{"man": Option{Mandatory: true}}, Name: "app", Output: &out}
+
+	config, origin, err := loader.LoadArgs(context.Background(), []string{"-help"})
+	if !errors.Is(err, ErrExit) {
+		t.Errorf("Unexpected error for help: %v, expected: %v", err, ErrExit)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+
+	if !strings.HasPrefix(out.String(), "app: \n") {
+		t.Errorf("Unexpected help:\n%s", out.String())
+	}
+}
+
 func sampleFlagsHandler(flags *flag.FlagSet) {
 	flags.SetOutput(io.Discard)
 }
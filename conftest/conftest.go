@@ -0,0 +1,172 @@
+// Package conftest helps test applications that extract configuration with
+// github.com/chiku/conf. It loads a conf.MultiLoader with given command-line
+// arguments, a fake environment and temporary configuration files, without
+// reading or changing the environment, arguments or output of the process.
+//
+// example_test.go
+//
+//	func TestPortFromFlags(t *testing.T) {
+//	    loader := newLoader()
+//	    loader.Environ = conftest.Environ("PORT=9090")
+//
+//	    result := conftest.Load(t, loader, "-port", "8080")
+//
+//	    conftest.AssertConfig(t, result, "port", "8080")
+//	    conftest.AssertOrigin(t, result, "port", conftest.Flags)
+//	}
+package conftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chiku/conf"
+)
+
+// Origins of configuration values, for use with AssertOrigin.
+const (
+	Flags       = conf.OriginFlags
+	Environment = conf.OriginEnvironment
+	Defaults    = conf.OriginDefaults
+)
+
+// JSON returns the origin of values from a JSON file, for use with
+// AssertOrigin.
+func JSON(file string) string {
+	return conf.OriginJSON + file
+}
+
+// A Result is the outcome of loading a conf.MultiLoader.
+type Result struct {
+	// Config is the configuration returned by the loader.
+	Config map[string]string
+
+	// Origin is the origin of each configuration returned by the loader.
+	Origin map[string]string
+
+	// Err is the error returned by the loader. It is conf.ErrExit when a
+	// built-in flag such as "-help" has printed its output.
+	Err error
+
+	// Output is what the loader printed, such as help.
+	Output string
+}
+
+// Environ returns a fake environment holding the given "name=value"
+// variables, for use as conf.MultiLoader.Environ.
+func Environ(vars ...string) func() []string {
+	environ := append([]string(nil), vars...)
+	return func() []string { return environ }
+}
+
+// File writes content to a file with the given name in a temporary
+// directory removed when the test ends, and returns its path. The test
+// fails immediately on error.
+func File(t testing.TB, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Unexpected error writing file: %s", err)
+	}
+
+	return path
+}
+
+// JSONFile writes content to a JSON configuration file removed when the
+// test ends, and returns its path. The test fails immediately on error.
+func JSONFile(t testing.TB, content string) string {
+	t.Helper()
+
+	return File(t, "config.json", content)
+}
+
+// Run loads loader with the given command-line arguments, without the
+// program name. Output is captured in the Result. If loader.Environ is nil,
+// the environment is empty rather than that of the process.
+func Run(loader conf.MultiLoader, args ...string) Result {
+	var output bytes.Buffer
+	loader.Output = &output
+	if loader.Environ == nil {
+		loader.Environ = Environ()
+	}
+
+	config, origin, err := loader.LoadArgs(context.Background(), args)
+
+	return Result{Config: config, Origin: origin, Err: err, Output: output.String()}
+}
+
+// Load loads loader as Run does. The test fails immediately if loading
+// fails.
+func Load(t testing.TB, loader conf.MultiLoader, args ...string) Result {
+	t.Helper()
+
+	result := Run(loader, args...)
+	if result.Err != nil {
+		t.Fatalf("Unexpected error loading configurations: %s", result.Err)
+	}
+
+	return result
+}
+
+// Help returns the help printed by loader when run with "-help". The test
+// fails immediately if help is not printed.
+func Help(t testing.TB, loader conf.MultiLoader) string {
+	t.Helper()
+
+	result := Run(loader, "-help")
+	if !errors.Is(result.Err, conf.ErrExit) {
+		t.Fatalf("Unexpected error printing help: %v", result.Err)
+	}
+
+	return result.Output
+}
+
+// AssertConfig reports a test failure if the configuration of key in
+// result is not expected.
+func AssertConfig(t testing.TB, result Result, key string, expected string) {
+	t.Helper()
+
+	actual, ok := result.Config[key]
+	if !ok {
+		t.Errorf("Configuration %q is missing, expected: %q", key, expected)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Configuration %q is %q, expected: %q", key, actual, expected)
+	}
+}
+
+// AssertOrigin reports a test failure if the origin of key in result is
+// not expected, such as Flags or JSON(file).
+func AssertOrigin(t testing.TB, result Result, key string, expected string) {
+	t.Helper()
+
+	actual, ok := result.Origin[key]
+	if !ok {
+		t.Errorf("Origin of %q is missing, expected: %q", key, expected)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Origin of %q is %q, expected: %q", key, actual, expected)
+	}
+}
+
+// AssertError reports a test failure if loading did not fail with an error
+// containing message.
+func AssertError(t testing.TB, result Result, message string) {
+	t.Helper()
+
+	if result.Err == nil {
+		t.Errorf("Expected error containing %q, loaded: %#v", message, result.Config)
+		return
+	}
+	if !strings.Contains(result.Err.Error(), message) {
+		t.Errorf("Error is %q, expected to contain: %q", result.Err, message)
+	}
+}
@@ -0,0 +1,131 @@
+package conftest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chiku/conf"
+)
+
+// recorder is a testing.TB recording the failures reported to it.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// sampleLoader returns a loader with options from every source.
+func sampleLoader() conf.MultiLoader {
+	return conf.MultiLoader{
+		Options: map[string]conf.Option{
+			"port":  conf.Option{Desc: "port to listen on", Default: "80"},
+			"host":  conf.Option{},
+			"mode":  conf.Option{},
+			"token": conf.Option{Mandatory: true},
+		},
+		JSONKey: "conf",
+		Usage:   "sample application",
+		Name:    "sample",
+	}
+}
+
+func TestLoad(t *testing.T) {
+	jsonFile := JSONFile(t, `{ "host": "host:json" }`)
+	loader := sampleLoader()
+	loader.Environ = Environ("mode=mode:env", "token=token:env")
+
+	result := Load(t, loader, "-token", "token:flags", "-conf", jsonFile)
+
+	AssertConfig(t, result, "token", "token:flags")
+	AssertOrigin(t, result, "token", Flags)
+	AssertConfig(t, result, "host", "host:json")
+	AssertOrigin(t, result, "host", JSON(jsonFile))
+	AssertConfig(t, result, "mode", "mode:env")
+	AssertOrigin(t, result, "mode", Environment)
+	AssertConfig(t, result, "port", "80")
+	AssertOrigin(t, result, "port", Defaults)
+}
+
+func TestRunIgnoresProcessEnvironment(t *testing.T) {
+	t.Setenv("token", "token:process")
+
+	result := Run(sampleLoader())
+
+	AssertError(t, result, "missing mandatory configurations: token")
+	if result.Config != nil || result.Origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", result.Config, result.Origin)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	help := Help(t, sampleLoader())
+
+	for _, expected := range []string{"sample: sample application", "  -port\n      port to listen on\n      default: \"80\""} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("Help does not contain %q:\n%s", expected, help)
+		}
+	}
+}
+
+func TestHelpIgnoresProcessColumns(t *testing.T) {
+	expected := Help(t, sampleLoader())
+
+	t.Setenv("COLUMNS", "20")
+	if help := Help(t, sampleLoader()); help != expected {
+		t.Error("Help changes with COLUMNS of the process")
+		t.Errorf("\nActual  :\n%s", help)
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+
+	loader := sampleLoader()
+	loader.Environ = Environ("COLUMNS=20")
+	if help := Help(t, loader); help == expected {
+		t.Errorf("Help does not change with COLUMNS of the loader environment:\n%s", help)
+	}
+}
+
+func TestRunWithUnknownFlag(t *testing.T) {
+	result := Run(sampleLoader(), "-unknown")
+
+	AssertError(t, result, "flag provided but not defined: -unknown")
+	if errors.Is(result.Err, conf.ErrExit) {
+		t.Error("Unexpected exit for an unknown flag")
+	}
+	if !strings.Contains(result.Output, "flag provided but not defined: -unknown") {
+		t.Errorf("Output does not report the unknown flag:\n%s", result.Output)
+	}
+}
+
+func TestAssertionFailures(t *testing.T) {
+	result := Result{
+		Config: map[string]string{"port": "8080"},
+		Origin: map[string]string{"port": Flags},
+	}
+	r := &recorder{}
+
+	AssertConfig(r, result, "port", "8080")
+	AssertOrigin(r, result, "port", Flags)
+	AssertConfig(r, result, "port", "9090")
+	AssertOrigin(r, result, "port", Environment)
+	AssertOrigin(r, result, "host", Defaults)
+	AssertError(r, result, "missing")
+
+	expected := []string{
+		`Configuration "port" is "8080", expected: "9090"`,
+		`Origin of "port" is "Flags", expected: "Environment"`,
+		`Origin of "host" is missing, expected: "Defaults"`,
+		`Expected error containing "missing", loaded: map[string]string{"port":"8080"}`,
+	}
+	if strings.Join(r.failures, "\n") != strings.Join(expected, "\n") {
+		t.Error("Failures don't match when assertions fail")
+		t.Errorf("\nActual  : %#v", r.failures)
+		t.Errorf("\nExpected: %#v", expected)
+	}
+}
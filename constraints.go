@@ -0,0 +1,166 @@
+package conf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Constraint is a relation between configuration keys. Constraints are
+// checked after all sources are merged and mandatory configurations are
+// verified. A configuration key is considered set if its value is not empty.
+type Constraint struct {
+	keys  []string
+	check func(config map[string]string) (violation string)
+}
+
+// Requires returns a Constraint that is violated if key is set and one or
+// more of the required keys are not set.
+func Requires(key string, required ...string) Constraint {
+	return Constraint{
+		keys: append([]string{key}, required...),
+		check: func(config map[string]string) string {
+			if config[key] == "" {
+				return ""
+			}
+			if missing := unsetKeys(config, required); len(missing) > 0 {
+				return fmt.Sprintf("%s requires %s", key, strings.Join(missing, ", "))
+			}
+			return ""
+		},
+	}
+}
+
+// Conflicts returns a Constraint that is violated if key is set along with
+// one or more of the others.
+func Conflicts(key string, others ...string) Constraint {
+	return Constraint{
+		keys: append([]string{key}, others...),
+		check: func(config map[string]string) string {
+			if config[key] == "" {
+				return ""
+			}
+			if present := setKeys(config, others); len(present) > 0 {
+				return fmt.Sprintf("%s conflicts with %s", key, strings.Join(present, ", "))
+			}
+			return ""
+		},
+	}
+}
+
+// ExactlyOne returns a Constraint that is violated unless exactly one of
+// the keys is set.
+func ExactlyOne(keys ...string) Constraint {
+	return Constraint{
+		keys: keys,
+		check: func(config map[string]string) string {
+			present := setKeys(config, keys)
+			switch len(present) {
+			case 1:
+				return ""
+			case 0:
+				return fmt.Sprintf("exactly one of %s must be set, found none", strings.Join(keys, ", "))
+			default:
+				return fmt.Sprintf("exactly one of %s must be set, found %s",
+					strings.Join(keys, ", "), strings.Join(present, ", "))
+			}
+		},
+	}
+}
+
+// AtLeastOne returns a Constraint that is violated if none of the keys
+// are set.
+func AtLeastOne(keys ...string) Constraint {
+	return Constraint{
+		keys: keys,
+		check: func(config map[string]string) string {
+			if len(setKeys(config, keys)) == 0 {
+				return fmt.Sprintf("at least one of %s must be set", strings.Join(keys, ", "))
+			}
+			return ""
+		},
+	}
+}
+
+// MandatoryWhen returns a Constraint that is violated if key is not set
+// while the configuration value of condKey equals condValue.
+func MandatoryWhen(key string, condKey string, condValue string) Constraint {
+	return Constraint{
+		keys: []string{key, condKey},
+		check: func(config map[string]string) string {
+			if config[condKey] == condValue && config[key] == "" {
+				return fmt.Sprintf("%s is mandatory when %s=%s", key, condKey, condValue)
+			}
+			return ""
+		},
+	}
+}
+
+// setKeys returns the keys that have a non-empty configuration value.
+func setKeys(config map[string]string, keys []string) []string {
+	var present []string
+	for _, key := range keys {
+		if config[key] != "" {
+			present = append(present, key)
+		}
+	}
+	return present
+}
+
+// unsetKeys returns the keys that have an empty configuration value.
+func unsetKeys(config map[string]string, keys []string) []string {
+	var missing []string
+	for _, key := range keys {
+		if config[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// validateConstraints returns an error if a Constraint is the zero value,
+// rather than one created by Requires, Conflicts, ExactlyOne, AtLeastOne or
+// MandatoryWhen, or refers to a key not present in Options.
+func (l MultiLoader) validateConstraints() error {
+	options := l.options()
+	unknown := make(map[string]bool)
+	for i, constraint := range l.Constraints {
+		if constraint.check == nil {
+			return fmt.Errorf("constraint %d is empty: create it with Requires, Conflicts, ExactlyOne, AtLeastOne or MandatoryWhen", i)
+		}
+		for _, key := range constraint.keys {
+			if _, ok := options[key]; !ok {
+				unknown[key] = true
+			}
+		}
+	}
+
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(unknown))
+		for name := range unknown {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("constraints refer to unknown options: %s", strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// verifyConstraints returns an error if one or more Constraints are
+// violated. The error message reports all the violations in the order
+// the Constraints are declared.
+func (l MultiLoader) verifyConstraints(config map[string]string) error {
+	var violations []string
+	for _, constraint := range l.Constraints {
+		if violation := constraint.check(config); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("constraint violations: %s", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
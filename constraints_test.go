@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadWithSatisfiedConstraints(t *testing.T) {
+	options := map[string]Option{
+		"tls-cert": Option{},
+		"tls-key":  Option{},
+		"token":    Option{},
+		"password": Option{},
+		"mode":     Option{Default: "prod"},
+		"db":       Option{},
+	}
+	loader := &MultiLoader{
+		Options: options,
+		Constraints: []Constraint{
+			Requires("tls-cert", "tls-key"),
+			Conflicts("token", "password"),
+			ExactlyOne("token", "password"),
+			AtLeastOne("tls-cert", "token"),
+			MandatoryWhen("db", "mode", "prod"),
+		},
+	}
+
+	config, _, err := loader.load([]string{"-tls-cert", "c", "-tls-key", "k", "-token", "t", "-db", "d"}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations satisfying constraints: %s", err)
+	}
+
+	expectedConfig := map[string]string{
+		"tls-cert": "c",
+		"tls-key":  "k",
+		"token":    "t",
+		"password": "",
+		"mode":     "prod",
+		"db":       "d",
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with constraints")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+}
+
+func TestLoadWithViolatedConstraintsError(t *testing.T) {
+	options := map[string]Option{
+		"tls-cert": Option{},
+		"tls-key":  Option{},
+		"token":    Option{},
+		"password": Option{},
+		"a":        Option{},
+		"b":        Option{},
+		"c":        Option{},
+		"x":        Option{},
+		"y":        Option{},
+		"mode":     Option{Default: "prod"},
+		"db":       Option{},
+	}
+	loader := &MultiLoader{
+		Options: options,
+		Constraints: []Constraint{
+			Requires("tls-cert", "tls-key"),
+			Conflicts("token", "password"),
+			ExactlyOne("a", "b", "c"),
+			AtLeastOne("x", "y"),
+			MandatoryWhen("db", "mode", "prod"),
+			ExactlyOne("token", "x"),
+		},
+	}
+
+	config, origin, err := loader.load([]string{"-tls-cert", "c", "-token", "t", "-password", "p", "-a", "1", "-c", "3"}, sampleFlagsHandler)
+	expectedMsg := "conf.Load: constraint violations: " +
+		"tls-cert requires tls-key; " +
+		"token conflicts with password; " +
+		"exactly one of a, b, c must be set, found a, c; " +
+		"at least one of x, y must be set; " +
+		"db is mandatory when mode=prod"
+	if err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for violated constraints")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for violated constraints")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestExactlyOneWithNoneSetError(t *testing.T) {
+	loader := &MultiLoader{
+		Options:     map[string]Option{"a": Option{}, "b": Option{}},
+		Constraints: []Constraint{ExactlyOne("a", "b")},
+	}
+
+	_, _, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: constraint violations: exactly one of a, b must be set, found none"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for exactly one constraint with none set")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestConstraintWithUnknownOptionError(t *testing.T) {
+	loader := &MultiLoader{
+		Options:     map[string]Option{"a": Option{}},
+		Constraints: []Constraint{Requires("a", "z"), Conflicts("y", "a")},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: constraints refer to unknown options: y, z"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for constraints referring to unknown options")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for constraints referring to unknown options")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestEmptyConstraintError(t *testing.T) {
+	loader := &MultiLoader{
+		Options:     map[string]Option{"a": Option{}, "b": Option{}},
+		Constraints: []Constraint{Requires("a", "b"), Constraint{}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	expectedMsg := "conf.Load: constraint 1 is empty: create it with Requires, Conflicts, ExactlyOne, AtLeastOne or MandatoryWhen"
+	if err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for empty constraint")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for empty constraint")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
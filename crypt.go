@@ -0,0 +1,166 @@
+package conf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// encryptedPrefix marks an encrypted configuration value, followed by the
+// ciphertext in standard base64 encoding.
+const encryptedPrefix = "enc:"
+
+// A Decrypter decrypts configuration values of the form "enc:<base64>".
+type Decrypter interface {
+	// Decrypt returns the plaintext for the ciphertext of the value of a
+	// configuration key. Decrypt should fail if the ciphertext was sealed
+	// for another key, so that an encrypted value cannot be moved from one
+	// key to another.
+	Decrypt(key string, ciphertext []byte) (plaintext []byte, err error)
+}
+
+// AESGCM is a Decrypter using AES in Galois/Counter Mode. The ciphertext
+// is the random nonce followed by the sealed plaintext, with the
+// configuration key as additional data. Create it with NewAESGCM,
+// NewAESGCMFromFile or NewAESGCMFromEnv.
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM returns an AESGCM with a key of 16, 24 or 32 bytes, selecting
+// AES-128, AES-192 or AES-256.
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromFile returns an AESGCM with the key held in a file, in
+// standard base64 encoding. Surrounding whitespace is ignored.
+func NewAESGCMFromFile(name string) (*AESGCM, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+
+	return newAESGCMFromBase64(string(content), name)
+}
+
+// NewAESGCMFromEnv returns an AESGCM with the key held in an environment
+// variable, in standard base64 encoding.
+func NewAESGCMFromEnv(name string) (*AESGCM, error) {
+	encoded, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("key environment variable is not set: %s", name)
+	}
+
+	return newAESGCMFromBase64(encoded, "$"+name)
+}
+
+// newAESGCMFromBase64 returns an AESGCM with a key in standard base64
+// encoding, read from the given location.
+func newAESGCMFromBase64(encoded string, location string) (*AESGCM, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding key in %s: %w", location, err)
+	}
+
+	return NewAESGCM(key)
+}
+
+// Decrypt opens a ciphertext sealed by Encrypt for the same configuration
+// key.
+func (a *AESGCM) Decrypt(key string, ciphertext []byte) ([]byte, error) {
+	size := a.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	return a.aead.Open(nil, ciphertext[:size], ciphertext[size:], []byte(key))
+}
+
+// Encrypt seals a plaintext for a configuration key with a random nonce.
+func (a *AESGCM) Encrypt(key string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	return a.aead.Seal(nonce, nonce, plaintext, []byte(key)), nil
+}
+
+// EncryptValue returns a configuration value of the form "enc:<base64>"
+// holding value encrypted for a configuration key, to be placed in a
+// configuration file. It decrypts only as the value of the same key.
+func (a *AESGCM) EncryptValue(key string, value string) (string, error) {
+	ciphertext, err := a.Encrypt(key, []byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decrypt replaces configuration values of the form "enc:<base64>" with
+// their plaintext using Decrypter, if present, and returns the keys of the
+// decrypted values. The error message reports every value that fails to
+// decrypt with its configuration key and origin.
+func (l MultiLoader) decrypt(config map[string]string, origin map[string]string) (map[string]bool, error) {
+	if l.Decrypter == nil {
+		return nil, nil
+	}
+
+	decrypted := make(map[string]bool)
+	var failed []string
+	for name, value := range config {
+		encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+		if !ok {
+			continue
+		}
+
+		plaintext, err := l.decryptValue(name, encoded)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s): %s", name, origin[name], err))
+			continue
+		}
+		config[name] = plaintext
+		decrypted[name] = true
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return nil, fmt.Errorf("error decrypting configurations: %s", strings.Join(failed, "; "))
+	}
+
+	return decrypted, nil
+}
+
+// decryptValue decodes and decrypts a ciphertext in standard base64
+// encoding, for the value of a configuration key.
+func (l MultiLoader) decryptValue(key string, encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("error decoding: %w", err)
+	}
+
+	plaintext, err := l.Decrypter.Decrypt(key, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
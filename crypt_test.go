@@ -0,0 +1,236 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testKey is a 32 byte AES-256 key.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestAESGCM returns an AESGCM for key. The test aborts on failure.
+func newTestAESGCM(t *testing.T, key []byte) *AESGCM {
+	t.Helper()
+
+	aesgcm, err := NewAESGCM(key)
+	if err != nil {
+		t.Fatalf("Unexpected error creating AES-GCM: %s", err)
+	}
+	return aesgcm
+}
+
+// encryptTestValue encrypts value for a configuration key with aesgcm. The
+// test aborts on failure.
+func encryptTestValue(t *testing.T, aesgcm *AESGCM, key string, value string) string {
+	t.Helper()
+
+	encrypted, err := aesgcm.EncryptValue(key, value)
+	if err != nil {
+		t.Fatalf("Unexpected error encrypting value: %s", err)
+	}
+	return encrypted
+}
+
+func TestLoadWithEncryptedValues(t *testing.T) {
+	aesgcm := newTestAESGCM(t, testKey)
+	password := encryptTestValue(t, aesgcm, "password", "secret:json")
+	jsonFile := createFile(t, `{ "password": "`+password+`", "user": "user:json" }`)
+	defer os.Remove(jsonFile)
+
+	loader := &MultiLoader{
+		Options:   map[string]Option{"password": Option{Mandatory: true, Secret: true}, "user": Option{}},
+		JSONKey:   "conf",
+		Decrypter: aesgcm,
+	}
+
+	config, origin, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading encrypted configurations: %s", err)
+	}
+
+	expectedConfig := map[string]string{"password": "secret:json", "user": "user:json"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with encrypted values")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"password": jsonOrig + ":" + jsonFile, "user": jsonOrig + ":" + jsonFile}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded with encrypted values")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadWithEncryptedValuesAndPrintConfigFlag(t *testing.T) {
+	aesgcm := newTestAESGCM(t, testKey)
+	var out strings.Builder
+	loader := &MultiLoader{
+		Options:        map[string]Option{"pw": Option{}, "user": Option{}},
+		Decrypter:      aesgcm,
+		Environ:        func() []string { return []string{"pw=" + encryptTestValue(t, aesgcm, "pw", "hunter2"), "user=admin"} },
+		PrintConfigKey: "print-config",
+		Output:         &out,
+	}
+
+	_, _, err := loader.load([]string{"-print-config", "table"}, sampleFlagsHandler)
+	if !errors.Is(err, ErrExit) {
+		t.Errorf("Expected exit request on printing configuration, got: %v", err)
+	}
+
+	expected := "" +
+		"KEY   VALUE       ORIGIN\n" +
+		"pw    <redacted>  Environment\n" +
+		"user  admin       Environment\n"
+	if out.String() != expected {
+		t.Error("Printed configuration with encrypted values doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestFingerprintWithEncryptedValues(t *testing.T) {
+	aesgcm := newTestAESGCM(t, testKey)
+	var out strings.Builder
+	loader := &MultiLoader{
+		Options:   map[string]Option{"pw": Option{}},
+		Decrypter: aesgcm,
+		Environ:   func() []string { return []string{"pw=" + encryptTestValue(t, aesgcm, "pw", "hunter2")} },
+		Logger:    newTestLogger(&out),
+	}
+
+	config, _, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading encrypted configurations: %s", err)
+	}
+
+	secretLoader := &MultiLoader{Options: map[string]Option{"pw": Option{Secret: true}}}
+	expected := `msg="configuration loaded" fingerprint=` + secretLoader.Fingerprint(config) + "\n"
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Error("Logged fingerprint doesn't treat decrypted values as secret")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected suffix: %q", expected)
+	}
+}
+
+func TestLoadWithEncryptedValuesWithoutDecrypter(t *testing.T) {
+	loader := &MultiLoader{Options: map[string]Option{"password": Option{Default: "enc:AAAA"}}}
+
+	config, _, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations: %s", err)
+	}
+	if config["password"] != "enc:AAAA" {
+		t.Errorf("Unexpected configuration without Decrypter: %q", config["password"])
+	}
+}
+
+func TestLoadWithUndecryptableValuesError(t *testing.T) {
+	other := newTestAESGCM(t, bytes.Repeat([]byte("k"), 32))
+	loader := &MultiLoader{
+		Options: map[string]Option{
+			"password": Option{},
+			"token":    Option{Default: "enc:not base64"},
+			"user":     Option{Default: "user:default"},
+		},
+		Decrypter: newTestAESGCM(t, testKey),
+	}
+
+	args := []string{"-password", encryptTestValue(t, other, "password", "secret:flags")}
+	config, origin, err := loader.load(args, sampleFlagsHandler)
+	if err == nil {
+		t.Fatal("Expected error loading undecryptable configurations")
+	}
+
+	expectedMessage := "conf.Load: error decrypting configurations: " +
+		"password (Flags): cipher: message authentication failed; " +
+		"token (Defaults): error decoding: illegal base64 data at input byte 3"
+	if err.Error() != expectedMessage {
+		t.Errorf("Unexpected error message: %q, expected: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestLoadWithEncryptedValueMovedToAnotherKeyError(t *testing.T) {
+	aesgcm := newTestAESGCM(t, testKey)
+	password := encryptTestValue(t, aesgcm, "password", "secret:json")
+	jsonFile := createFile(t, `{ "user": "`+password+`" }`)
+	defer os.Remove(jsonFile)
+
+	loader := &MultiLoader{
+		Options:   map[string]Option{"password": Option{Secret: true}, "user": Option{}},
+		JSONKey:   "conf",
+		Decrypter: aesgcm,
+	}
+
+	config, origin, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
+	expectedMessage := "conf.Load: error decrypting configurations: " +
+		"user (JSON:" + jsonFile + "): cipher: message authentication failed"
+	if err == nil || err.Error() != expectedMessage {
+		t.Errorf("Unexpected error: %v, expected: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestNewAESGCMFromFileAndEnv(t *testing.T) {
+	encodedKey := base64.StdEncoding.EncodeToString(testKey)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(encodedKey+"\n"), 0o600); err != nil {
+		t.Fatalf("Unexpected error writing key file: %s", err)
+	}
+	t.Setenv("CONF_TEST_KEY", encodedKey)
+
+	fromFile, err := NewAESGCMFromFile(keyFile)
+	if err != nil {
+		t.Fatalf("Unexpected error creating AES-GCM from key file: %s", err)
+	}
+	fromEnv, err := NewAESGCMFromEnv("CONF_TEST_KEY")
+	if err != nil {
+		t.Fatalf("Unexpected error creating AES-GCM from environment: %s", err)
+	}
+
+	ciphertext, err := fromFile.Encrypt("password", []byte("secret"))
+	if err != nil {
+		t.Fatalf("Unexpected error encrypting: %s", err)
+	}
+	plaintext, err := fromEnv.Decrypt("password", ciphertext)
+	if err != nil || string(plaintext) != "secret" {
+		t.Errorf("Unexpected decryption: %q, %v", plaintext, err)
+	}
+}
+
+func TestNewAESGCMErrors(t *testing.T) {
+	if _, err := NewAESGCM([]byte("short")); err == nil || !strings.HasPrefix(err.Error(), "error creating cipher: ") {
+		t.Errorf("Unexpected error for short key: %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("not base64"), 0o600); err != nil {
+		t.Fatalf("Unexpected error writing key file: %s", err)
+	}
+	if _, err := NewAESGCMFromFile(keyFile); err == nil || !strings.HasPrefix(err.Error(), "error decoding key in "+keyFile) {
+		t.Errorf("Unexpected error for invalid key file: %v", err)
+	}
+
+	if _, err := NewAESGCMFromEnv("CONF_TEST_MISSING_KEY"); err == nil {
+		t.Error("Expected error for missing key environment variable")
+	}
+
+	aesgcm := newTestAESGCM(t, testKey)
+	if _, err := aesgcm.Decrypt("password", []byte("short")); err == nil {
+		t.Error("Expected error decrypting short ciphertext")
+	}
+}
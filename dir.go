@@ -0,0 +1,99 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kubernetesDataDir is the symbolic link Kubernetes keeps in a mounted
+// ConfigMap or Secret, pointing to the directory of the current version of
+// the files.
+const kubernetesDataDir = "..data"
+
+// DirectorySource is a Source reading a directory with one file for each
+// configuration key, such as a ConfigMap or Secret mounted by Kubernetes.
+// The file name is the configuration key and the file content, with
+// trailing newlines removed, is the value. The origin of a value is
+// "Directory:<file>". Files whose names start with a dot (.) are skipped.
+//
+// Kubernetes updates a mounted directory by pointing the "..data" symbolic
+// link to a new version of the files. When present, the files are read
+// through "..data", so that all of them come from the same version.
+type DirectorySource struct {
+	// Dir is the directory holding the files.
+	Dir string
+
+	// Optional is true if a missing Dir provides no values instead of
+	// failing.
+	Optional bool
+}
+
+// Fetch reads the files of the given configuration keys. It stops when ctx
+// is done.
+func (s DirectorySource) Fetch(ctx context.Context, keys []string) (values map[string]string, origin map[string]string, err error) {
+	dir, err := s.dataDir()
+	if errors.Is(err, fs.ErrNotExist) && s.Optional {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values = make(map[string]string)
+	origin = make(map[string]string)
+	for _, key := range keys {
+		if key == "" || strings.HasPrefix(key, ".") || strings.ContainsAny(key, `/\`) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		name := filepath.Join(dir, key)
+		info, err := os.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		values[key] = strings.TrimRight(string(content), "\r\n")
+		origin[key] = "Directory:" + filepath.Join(s.Dir, key)
+	}
+
+	return values, origin, nil
+}
+
+// String returns "Directory:<dir>", naming the source in errors.
+func (s DirectorySource) String() string {
+	return "Directory:" + s.Dir
+}
+
+// dataDir returns the directory the files are read from, which is the
+// target of "..data" in Dir if present, or else Dir.
+func (s DirectorySource) dataDir() (string, error) {
+	info, err := os.Stat(s.Dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", s.Dir)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join(s.Dir, kubernetesDataDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s.Dir, nil
+	}
+	return dir, err
+}
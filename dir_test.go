@@ -0,0 +1,127 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mountConfigMap lays out files in dir as Kubernetes mounts a ConfigMap,
+// with each file a symbolic link through "..data" to a versioned directory.
+func mountConfigMap(t *testing.T, dir string, version string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, version), name, content)
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(kubernetesDataDir, name), link); err != nil {
+			t.Fatalf("Unexpected error linking file: %s", err)
+		}
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(version, tmp); err != nil {
+		t.Fatalf("Unexpected error linking data directory: %s", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, kubernetesDataDir)); err != nil {
+		t.Fatalf("Unexpected error replacing data directory: %s", err)
+	}
+}
+
+func TestLoadFromDirectorySource(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "man", "man:dir\n")
+	writeFile(t, dir, "db.host", "host:dir\r\n\n")
+	writeFile(t, dir, ".opt", "hidden")
+	writeFile(t, filepath.Join(dir, "opt"), "nested", "nested")
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}, "opt": Option{Default: "opt:default"}},
+		Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		Sources: []Source{DirectorySource{Dir: dir}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from directory: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:dir", "opt": "opt:default", "db.host": "host:dir"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from directory")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{
+		"man":     "Directory:" + filepath.Join(dir, "man"),
+		"opt":     defaultsOrig,
+		"db.host": "Directory:" + filepath.Join(dir, "db.host"),
+	}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from directory")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadFromKubernetesMountedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	mountConfigMap(t, dir, "..2024_01_01_00_00_00.1", map[string]string{"man": "man:v1\n", "opt": "opt:v1\n"})
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}, "opt": Option{}},
+		Sources: []Source{DirectorySource{Dir: dir}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from mounted directory: %s", err)
+	}
+	if !reflect.DeepEqual(config, map[string]string{"man": "man:v1", "opt": "opt:v1"}) {
+		t.Errorf("Unexpected configurations from mounted directory: %#v", config)
+	}
+	if origin["man"] != "Directory:"+filepath.Join(dir, "man") {
+		t.Errorf("Unexpected origin from mounted directory: %q", origin["man"])
+	}
+
+	mountConfigMap(t, dir, "..2024_01_02_00_00_00.2", map[string]string{"man": "man:v2\n", "opt": "opt:v2\n"})
+
+	config, _, err = loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from updated mounted directory: %s", err)
+	}
+	if !reflect.DeepEqual(config, map[string]string{"man": "man:v2", "opt": "opt:v2"}) {
+		t.Errorf("Unexpected configurations from updated mounted directory: %#v", config)
+	}
+}
+
+func TestLoadFromMissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Default: "man:default"}},
+		Sources: []Source{DirectorySource{Dir: dir}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err == nil || !strings.HasPrefix(err.Error(), "conf.Load: error reading Directory:"+dir+": ") {
+		t.Errorf("Unexpected error loading configurations from missing directory: %v", err)
+	}
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+
+	loader.Sources = []Source{DirectorySource{Dir: dir, Optional: true}}
+	config, _, err = loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from missing optional directory: %s", err)
+	}
+	if config["man"] != "man:default" {
+		t.Errorf("Unexpected configuration from missing optional directory: %q", config["man"])
+	}
+}
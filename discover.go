@@ -0,0 +1,138 @@
+package conf
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// searchDirs returns SearchPaths, or the default directories searched for
+// SearchFile if SearchPaths is empty. The defaults are the working
+// directory, the application directory in the user configuration directory
+// (such as $XDG_CONFIG_HOME/<Name>) and /etc/<Name>.
+func (l MultiLoader) searchDirs() []string {
+	if len(l.SearchPaths) > 0 {
+		return l.SearchPaths
+	}
+
+	dirs := []string{"."}
+	if userDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(userDir, l.name()))
+	}
+	return append(dirs, filepath.Join("/etc", l.name()))
+}
+
+// searchCandidates returns the paths searched for SearchFile, in order.
+func (l MultiLoader) searchCandidates() []string {
+	var candidates []string
+	for _, dir := range l.searchDirs() {
+		candidates = append(candidates, filepath.Join(dir, l.SearchFile))
+	}
+	return candidates
+}
+
+// DiscoverFile returns the path of the first regular file named SearchFile
+// found in SearchPaths, or in the default directories if SearchPaths is
+// empty. It returns an empty string if SearchFile is empty or no file is
+// found. Load uses the discovered file when no JSON file is given by flag,
+// JSONEnv or JSONDefault.
+func (l MultiLoader) DiscoverFile() string {
+	if l.SearchFile == "" {
+		return ""
+	}
+
+	for _, candidate := range l.searchCandidates() {
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// ConfigFiles returns the JSON configuration files Load reads for the given
+// command-line arguments, and how they were selected. The files are
+// selected, in order of preference, by the JSONKey flag (OriginFlags), the
+// JSONEnv environment variable (OriginEnvironment), JSONDefault
+// (OriginDefaults) or DiscoverFile (OriginSearch). It returns an empty
+// selection if there are no files, and an error if the arguments do not
+// parse or RequireFile is true and there are no files.
+func (l MultiLoader) ConfigFiles(args []string) (files []string, from string, err error) {
+	if err := l.validate(); err != nil {
+		return nil, "", fmt.Errorf("conf.ConfigFiles: %w", err)
+	}
+
+	_, flagFiles, err := l.parseFlags(args, func(flags *flag.FlagSet) { flags.SetOutput(io.Discard) })
+	if err != nil {
+		return nil, "", fmt.Errorf("conf.ConfigFiles: %w", err)
+	}
+
+	files, from, err = l.configFiles(flagFiles)
+	if err != nil {
+		return nil, "", fmt.Errorf("conf.ConfigFiles: %w", err)
+	}
+
+	return files, from, nil
+}
+
+// configFiles returns the JSON files to load and how they were selected.
+// These are the files given by flag, or else by JSONEnv, or else by
+// JSONDefault, or else the file found by DiscoverFile. It returns an error
+// if RequireFile is true and there is no file to load.
+func (l MultiLoader) configFiles(flagFiles []string) (files []string, from string, err error) {
+	if len(flagFiles) > 0 {
+		return flagFiles, OriginFlags, nil
+	}
+
+	if l.JSONEnv != "" {
+		if files := filepath.SplitList(l.getenv(l.JSONEnv)); len(files) > 0 {
+			return files, OriginEnvironment, nil
+		}
+	}
+
+	if files := filepath.SplitList(l.JSONDefault); len(files) > 0 {
+		return files, OriginDefaults, nil
+	}
+
+	if discovered := l.DiscoverFile(); discovered != "" {
+		return []string{discovered}, OriginSearch, nil
+	}
+
+	if !l.RequireFile {
+		return nil, "", nil
+	}
+
+	var given []string
+	if l.JSONKey != "" {
+		given = append(given, "-"+l.JSONKey)
+	}
+	if l.JSONEnv != "" {
+		given = append(given, "$"+l.JSONEnv)
+	}
+	if l.SearchFile == "" {
+		return nil, "", fmt.Errorf("missing configuration file: %s is required", strings.Join(given, " or "))
+	}
+
+	searched := strings.Join(l.searchCandidates(), ", ")
+	if len(given) == 0 {
+		return nil, "", fmt.Errorf("missing configuration file: none of %s found", searched)
+	}
+	return nil, "", fmt.Errorf("missing configuration file: %s not given and none of %s found",
+		strings.Join(given, " or "), searched)
+}
+
+// validateDiscovery checks that SearchFile is a file name and that a
+// configuration file can be found when RequireFile is true.
+func (l MultiLoader) validateDiscovery() error {
+	if l.SearchFile != "" && filepath.Base(l.SearchFile) != l.SearchFile {
+		return fmt.Errorf("SearchFile cannot contain a directory: %s", l.SearchFile)
+	}
+	if l.RequireFile && l.JSONKey == "" && l.JSONEnv == "" && l.JSONDefault == "" && l.SearchFile == "" {
+		return errors.New("RequireFile needs JSONKey, JSONEnv, JSONDefault or SearchFile")
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package conf
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromDiscoveredFile(t *testing.T) {
+	root := t.TempDir()
+	userDir := filepath.Join(root, "user")
+	etcDir := filepath.Join(root, "etc")
+	writeFile(t, etcDir, "app.json", `{ "man": "man:etc" }`)
+	userFile := writeFile(t, userDir, "app.json", `{ "man": "man:user" }`)
+
+	options := map[string]Option{"man": Option{Mandatory: true}}
+	loader := &MultiLoader{
+		Options:     options,
+		JSONKey:     "conf",
+		SearchFile:  "app.json",
+		SearchPaths: []string{filepath.Join(root, "missing"), userDir, etcDir},
+	}
+
+	if discovered := loader.DiscoverFile(); discovered != userFile {
+		t.Errorf("Unexpected discovered file: %q, expected: %q", discovered, userFile)
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from discovered file: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:user"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from discovered file")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"man": jsonOrig + ":" + userFile}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from discovered file")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadFromFlagFileSkipsDiscovery(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "app.json", `{ "man": "man:discovered" }`)
+	flagFile := writeFile(t, root, "flag.json", `{ "man": "man:flag" }`)
+
+	options := map[string]Option{"man": Option{Mandatory: true}}
+	loader := &MultiLoader{Options: options, JSONKey: "conf", SearchFile: "app.json", SearchPaths: []string{root}}
+
+	config, _, err := loader.load([]string{"-conf", flagFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from flag file: %s", err)
+	}
+
+	if config["man"] != "man:flag" {
+		t.Errorf("Unexpected configuration with flag file and discovery: %#v", config)
+	}
+}
+
+func TestDefaultSearchPaths(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", root)
+	t.Setenv("HOME", root)
+	t.Chdir(t.TempDir())
+
+	appFile := writeFile(t, filepath.Join(root, "my-app"), "config.json", `{}`)
+	loader := &MultiLoader{Name: "my-app", SearchFile: "config.json"}
+
+	if discovered := loader.DiscoverFile(); discovered != appFile {
+		t.Errorf("Unexpected discovered file: %q, expected: %q", discovered, appFile)
+	}
+
+	writeFile(t, ".", "config.json", `{}`)
+	if discovered := loader.DiscoverFile(); discovered != "config.json" {
+		t.Errorf("Unexpected discovered file: %q, expected file in working directory", discovered)
+	}
+}
+
+func TestRequireFileError(t *testing.T) {
+	root := t.TempDir()
+
+	cases := []struct {
+		loader      MultiLoader
+		expectedMsg string
+	}{
+		{
+			MultiLoader{JSONKey: "conf", RequireFile: true},
+			"conf.Load: missing configuration file: -conf is required",
+		},
+		{
+			MultiLoader{SearchFile: "app.json", SearchPaths: []string{root}, RequireFile: true},
+			"conf.Load: missing configuration file: none of " + filepath.Join(root, "app.json") + " found",
+		},
+		{
+			MultiLoader{JSONKey: "conf", SearchFile: "app.json", SearchPaths: []string{root, "/x"}, RequireFile: true},
+			"conf.Load: missing configuration file: -conf not given and none of " +
+				filepath.Join(root, "app.json") + ", /x/app.json found",
+		},
+		{
+			MultiLoader{RequireFile: true},
+			"conf.Load: RequireFile needs JSONKey, JSONEnv, JSONDefault or SearchFile",
+		},
+		{
+			MultiLoader{SearchFile: "conf/app.json"},
+			"conf.Load: SearchFile cannot contain a directory: conf/app.json",
+		},
+	}
+	for _, c := range cases {
+		config, origin, err := c.loader.load(nil, sampleFlagsHandler)
+		if err == nil || err.Error() != c.expectedMsg {
+			t.Error("Invalid error message for missing configuration file")
+			t.Errorf("Actual  : %q", err)
+			t.Errorf("Expected: %q", c.expectedMsg)
+		}
+
+		if len(config) != 0 || len(origin) != 0 {
+			t.Error("Unexpected invalid values for missing configuration file")
+			t.Errorf("Config: %#v", config)
+			t.Errorf("Origin: %#v", origin)
+		}
+	}
+}
+
+func TestRequireFileFromDefault(t *testing.T) {
+	defaultFile := writeFile(t, t.TempDir(), "default.json", `{ "man": "man:default" }`)
+	loader := &MultiLoader{Options: map[string]Option{"man": Option{}}, JSONDefault: defaultFile, RequireFile: true}
+
+	config, _, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from required default file: %s", err)
+	}
+
+	if config["man"] != "man:default" {
+		t.Errorf("Unexpected configuration from required default file: %#v", config)
+	}
+}
+
+func TestConfigFilesSelection(t *testing.T) {
+	root := t.TempDir()
+	flagFile := writeFile(t, root, "flag.json", `{ "man": "man:flag" }`)
+	envFile := writeFile(t, root, "env.json", `{ "man": "man:env" }`)
+	envOverlayFile := writeFile(t, root, "env-overlay.json", `{ "man": "man:env-overlay" }`)
+	defaultFile := writeFile(t, root, "default.json", `{ "man": "man:default" }`)
+	searchFile := writeFile(t, root, "search.json", `{ "man": "man:search" }`)
+
+	options := map[string]Option{"man": Option{Mandatory: true}}
+	loader := &MultiLoader{
+		Options:     options,
+		JSONKey:     "conf",
+		JSONEnv:     "APP_CONFIG",
+		JSONDefault: defaultFile,
+		SearchFile:  "search.json",
+		SearchPaths: []string{root},
+	}
+
+	cases := []struct {
+		args          []string
+		env           string
+		jsonDefault   string
+		expectedFiles []string
+		expectedFrom  string
+		expectedValue string
+	}{
+		{[]string{"-conf", flagFile}, envFile, defaultFile, []string{flagFile}, "Flags", "man:flag"},
+		{nil, envFile + string(filepath.ListSeparator) + envOverlayFile, defaultFile,
+			[]string{envFile, envOverlayFile}, "Environment", "man:env-overlay"},
+		{nil, "", defaultFile, []string{defaultFile}, "Defaults", "man:default"},
+		{nil, "", "", []string{searchFile}, OriginSearch, "man:search"},
+	}
+	for _, c := range cases {
+		t.Setenv("APP_CONFIG", c.env)
+		loader.JSONDefault = c.jsonDefault
+
+		files, from, err := loader.ConfigFiles(c.args)
+		if err != nil {
+			t.Fatalf("Unexpected error selecting configuration files: %s", err)
+		}
+		if !reflect.DeepEqual(files, c.expectedFiles) || from != c.expectedFrom {
+			t.Error("Selected configuration files don't match")
+			t.Errorf("\nActual  : %#v from %q", files, from)
+			t.Errorf("\nExpected: %#v from %q", c.expectedFiles, c.expectedFrom)
+		}
+
+		var out strings.Builder
+		loader.Logger = newTestLogger(&out)
+		config, origin, err := loader.load(c.args, sampleFlagsHandler)
+		if err != nil {
+			t.Fatalf("Unexpected error loading configurations from selected files: %s", err)
+		}
+		if expectedLog := " selected_by=" + c.expectedFrom + "\n"; !strings.Contains(out.String(), expectedLog) {
+			t.Errorf("Log doesn't report how configuration files were selected: %q, expected: %q", out.String(), expectedLog)
+		}
+		lastFile := c.expectedFiles[len(c.expectedFiles)-1]
+		if config["man"] != c.expectedValue || origin["man"] != jsonOrig+":"+lastFile {
+			t.Error("Configurations don't match when loaded from selected files")
+			t.Errorf("\nActual  : %q from %q", config["man"], origin["man"])
+			t.Errorf("\nExpected: %q from %q", c.expectedValue, jsonOrig+":"+lastFile)
+		}
+	}
+}
+
+func TestConfigFilesRequiredFromFlagOrEnvironmentError(t *testing.T) {
+	loader := &MultiLoader{JSONKey: "conf", JSONEnv: "APP_CONFIG", RequireFile: true}
+
+	_, _, err := loader.ConfigFiles(nil)
+	if expectedMsg := "conf.ConfigFiles: missing configuration file: -conf or $APP_CONFIG is required"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for missing configuration file")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
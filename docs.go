@@ -0,0 +1,144 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteManPage writes a reference for the application as a roff man page
+// in section 1 to w. It documents Usage and every option with its
+// description, default, whether it is mandatory, its environment variable
+// and its key in the JSON configuration file, grouped as in help. The
+// output is stable, so it can be kept in sync with a go:generate directive
+// running a program that calls WriteManPage.
+func (l MultiLoader) WriteManPage(w io.Writer) error {
+	data := l.helpData(l.name())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1\n", roffEscape(strings.ToUpper(data.Program)))
+	b.WriteString(".SH NAME\n")
+	if data.Usage != "" {
+		fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(data.Program), roffEscape(data.Usage))
+	} else {
+		fmt.Fprintf(&b, "%s\n", roffEscape(data.Program))
+	}
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n[\\fIOPTIONS\\fR]\n", roffEscape(data.Program))
+	b.WriteString(".SH OPTIONS\n")
+
+	for _, section := range data.Sections {
+		fmt.Fprintf(&b, ".SS %s\n", roffEscape(section.Title))
+		if section.Desc != "" {
+			fmt.Fprintf(&b, "%s\n", roffEscape(section.Desc))
+		}
+		for _, option := range section.Options {
+			fmt.Fprintf(&b, ".TP\n.B %s\n", roffEscape(option.Flag))
+			lines := docLines(option)
+			for i, line := range lines {
+				if i > 0 {
+					b.WriteString(".br\n")
+				}
+				fmt.Fprintf(&b, "%s\n", roffEscape(line))
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error writing man page: %w", err)
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes a reference for the application in Markdown to w.
+// It documents Usage and every option with its description, default,
+// whether it is mandatory, its environment variable and its key in the
+// JSON configuration file, with a table for each section of help. The
+// output is stable, so it can be kept in sync with a go:generate directive
+// running a program that calls WriteMarkdown.
+func (l MultiLoader) WriteMarkdown(w io.Writer) error {
+	data := l.helpData(l.name())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", data.Program)
+	if data.Usage != "" {
+		fmt.Fprintf(&b, "\n%s\n", data.Usage)
+	}
+
+	for _, section := range data.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.Title)
+		if section.Desc != "" {
+			fmt.Fprintf(&b, "%s\n\n", section.Desc)
+		}
+		b.WriteString("| Flag | Description | Default | Mandatory | Environment | File key |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, option := range section.Options {
+			desc := option.Desc
+			if len(option.Values) > 0 {
+				desc = strings.TrimSpace(desc + " One of: " + strings.Join(option.Values, ", ") + ".")
+			}
+			mandatory := ""
+			if option.Mandatory {
+				mandatory = "yes"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCode(option.Flag), markdownEscape(desc), markdownCode(option.Default),
+				mandatory, markdownCode(option.Env), markdownCode(option.FileKey))
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error writing markdown: %w", err)
+	}
+
+	return nil
+}
+
+// docLines returns the lines describing an option in a man page.
+func docLines(option HelpOption) []string {
+	var lines []string
+	if option.Desc != "" {
+		lines = append(lines, option.Desc)
+	}
+	if len(option.Values) > 0 {
+		lines = append(lines, "Values: "+strings.Join(option.Values, ", "))
+	}
+	if option.Default != "" {
+		lines = append(lines, "Default: "+option.Default)
+	}
+	if option.Mandatory {
+		lines = append(lines, "Mandatory.")
+	}
+	if option.Env != "" {
+		lines = append(lines, "Environment: "+option.Env)
+	}
+	if option.FileKey != "" {
+		lines = append(lines, "File key: "+option.FileKey)
+	}
+	return lines
+}
+
+// roffEscape escapes text for roff. Backslashes and minus signs are
+// escaped, and a leading control character is neutralised.
+func roffEscape(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`, "\n", " ").Replace(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// markdownEscape escapes text for a Markdown table cell.
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+// markdownCode formats text as inline code in a Markdown table cell, or
+// returns an empty string for empty text.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + markdownEscape(text) + "`"
+}
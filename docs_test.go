@@ -0,0 +1,141 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+func docsLoader() *MultiLoader {
+	return &MultiLoader{
+		Name: "my-app",
+		Options: map[string]Option{
+			"foo":  Option{Desc: "a description for foo", Default: "default foo", Mandatory: true},
+			"mode": Option{Desc: "run | mode"}.OneOf("dev", "prod"),
+		},
+		Groups: []Group{
+			{Name: "db", Desc: "database connection", Options: map[string]Option{"host": Option{Default: "localhost"}}},
+		},
+		JSONKey: "conf",
+		Usage:   "Example application",
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	var out strings.Builder
+	if err := docsLoader().WriteManPage(&out); err != nil {
+		t.Fatalf("Unexpected error writing man page: %s", err)
+	}
+
+	expected := `.TH MY\-APP 1
+.SH NAME
+my\-app \- Example application
+.SH SYNOPSIS
+.B my\-app
+[\fIOPTIONS\fR]
+.SH OPTIONS
+.SS Mandatory options
+.TP
+.B \-foo
+a description for foo
+.br
+Default: default foo
+.br
+Mandatory.
+.br
+Environment: foo
+.br
+File key: foo
+.SS Options
+.TP
+.B \-mode
+run | mode
+.br
+Values: dev, prod
+.br
+Environment: mode
+.br
+File key: mode
+.SS db
+database connection
+.TP
+.B \-db.host
+Default: localhost
+.br
+Environment: DB_HOST
+.br
+File key: db.host
+.SS Other options
+.TP
+.B \-conf <file>
+JSON configuration file, repeated to override earlier files
+.TP
+.B \-help
+show this help and exit
+`
+	if out.String() != expected {
+		t.Error("Man page doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var out strings.Builder
+	if err := docsLoader().WriteMarkdown(&out); err != nil {
+		t.Fatalf("Unexpected error writing markdown: %s", err)
+	}
+
+	expected := "# my-app\n" +
+		"\n" +
+		"Example application\n" +
+		"\n" +
+		"## Mandatory options\n" +
+		"\n" +
+		"| Flag | Description | Default | Mandatory | Environment | File key |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `-foo` | a description for foo | `default foo` | yes | `foo` | `foo` |\n" +
+		"\n" +
+		"## Options\n" +
+		"\n" +
+		"| Flag | Description | Default | Mandatory | Environment | File key |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `-mode` | run \\| mode One of: dev, prod. |  |  | `mode` | `mode` |\n" +
+		"\n" +
+		"## db\n" +
+		"\n" +
+		"database connection\n" +
+		"\n" +
+		"| Flag | Description | Default | Mandatory | Environment | File key |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `-db.host` |  | `localhost` |  | `DB_HOST` | `db.host` |\n" +
+		"\n" +
+		"## Other options\n" +
+		"\n" +
+		"| Flag | Description | Default | Mandatory | Environment | File key |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `-conf <file>` | JSON configuration file, repeated to override earlier files |  |  |  |  |\n" +
+		"| `-help` | show this help and exit |  |  |  |  |\n"
+	if out.String() != expected {
+		t.Error("Markdown doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestRoffEscape(t *testing.T) {
+	cases := map[string]string{
+		"plain":      "plain",
+		"-flag":      `\-flag`,
+		`a\b`:        `a\eb`,
+		".starts":    `\&.starts`,
+		"'quoted'":   `\&'quoted'`,
+		"two\nlines": "two lines",
+	}
+	for text, expected := range cases {
+		if escaped := roffEscape(text); escaped != expected {
+			t.Errorf("Invalid roff escape for %q", text)
+			t.Errorf("Actual  : %q", escaped)
+			t.Errorf("Expected: %q", expected)
+		}
+	}
+}
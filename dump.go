@@ -0,0 +1,109 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// FormatTable is an aligned table of configuration keys, values and
+// origins, rendered by WriteConfig.
+const FormatTable = "table"
+
+// redacted replaces the values of Secret options and decrypted values
+// rendered by WriteConfig or logged.
+const redacted = "<redacted>"
+
+// A dumpEntry is a configuration value and its origin rendered by
+// WriteConfig.
+type dumpEntry struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// WriteConfig writes the configuration and origin returned by Load to w,
+// sorted by configuration key. The format is one of "table", "json" or
+// "env". The values of Secret options are redacted. Values decrypted by
+// Load are redacted as well when printed with PrintConfigKey, but not by
+// WriteConfig, which cannot tell them apart; mark their options Secret.
+func (l MultiLoader) WriteConfig(w io.Writer, config map[string]string, origin map[string]string, format string) error {
+	return l.writeConfig(w, config, origin, format, nil)
+}
+
+// writeConfig writes the configuration and origin as WriteConfig does,
+// redacting the values of the keys in secret along with those of Secret
+// options.
+func (l MultiLoader) writeConfig(
+	w io.Writer,
+	config map[string]string,
+	origin map[string]string,
+	format string,
+	secret map[string]bool,
+) error {
+	keys := make([]string, 0, len(config))
+	for name := range config {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	options := l.options()
+	values := make(map[string]string, len(config))
+	for _, name := range keys {
+		values[name] = config[name]
+		if (options[name].Secret || secret[name]) && config[name] != "" {
+			values[name] = redacted
+		}
+	}
+
+	var err error
+	switch format {
+	case FormatTable:
+		err = writeTable(w, keys, values, origin)
+	case FormatJSON:
+		err = writeJSON(w, keys, values, origin)
+	case FormatEnv:
+		err = l.writeEnv(w, keys, values, origin)
+	default:
+		return fmt.Errorf("unknown configuration format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s configuration: %w", format, err)
+	}
+
+	return nil
+}
+
+// writeTable writes the configuration as an aligned table to w.
+func writeTable(w io.Writer, keys []string, values map[string]string, origin map[string]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tORIGIN")
+	for _, name := range keys {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, values[name], origin[name])
+	}
+	return tw.Flush()
+}
+
+// writeJSON writes the configuration as a JSON object of keys to values
+// and origins to w.
+func writeJSON(w io.Writer, keys []string, values map[string]string, origin map[string]string) error {
+	entries := make(map[string]dumpEntry, len(keys))
+	for _, name := range keys {
+		entries[name] = dumpEntry{Value: values[name], Origin: origin[name]}
+	}
+
+	return encodeJSON(w, entries)
+}
+
+// writeEnv writes the configuration as an environment file to w, with
+// the origin of each value as a preceding comment.
+func (l MultiLoader) writeEnv(w io.Writer, keys []string, values map[string]string, origin map[string]string) error {
+	var b strings.Builder
+	for _, name := range keys {
+		fmt.Fprintf(&b, "# %s\n%s=%s\n", origin[name], l.envName(name), envQuote(values[name]))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,133 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteConfig(t *testing.T) {
+	options := map[string]Option{
+		"user":     Option{},
+		"password": Option{Secret: true},
+		"token":    Option{Secret: true},
+		"url":      Option{},
+	}
+	loader := &MultiLoader{Options: options}
+	config := map[string]string{"user": "admin", "password": "s3cret", "token": "", "url": "http://x y"}
+	origin := map[string]string{"user": flagsOrig, "password": envOrig, "token": defaultsOrig, "url": jsonOrig}
+
+	cases := map[string]string{
+		FormatTable: "" +
+			"KEY       VALUE       ORIGIN\n" +
+			"password  <redacted>  Environment\n" +
+			"token                 Defaults\n" +
+			"url       http://x y  JSON\n" +
+			"user      admin       Flags\n",
+		FormatJSON: `{
+  "password": {
+    "value": "<redacted>",
+    "origin": "Environment"
+  },
+  "token": {
+    "value": "",
+    "origin": "Defaults"
+  },
+  "url": {
+    "value": "http://x y",
+    "origin": "JSON"
+  },
+  "user": {
+    "value": "admin",
+    "origin": "Flags"
+  }
+}
+`,
+		FormatEnv: "" +
+			"# Environment\npassword=\"<redacted>\"\n" +
+			"# Defaults\ntoken=\n" +
+			"# JSON\nurl=\"http://x y\"\n" +
+			"# Flags\nuser=admin\n",
+	}
+	for format, expected := range cases {
+		var out strings.Builder
+		if err := loader.WriteConfig(&out, config, origin, format); err != nil {
+			t.Fatalf("Unexpected error writing %s configuration: %s", format, err)
+		}
+		if out.String() != expected {
+			t.Errorf("Written %s configuration doesn't match", format)
+			t.Errorf("\nActual  : %q", out.String())
+			t.Errorf("\nExpected: %q", expected)
+		}
+	}
+}
+
+func TestWriteConfigUnknownFormatError(t *testing.T) {
+	loader := &MultiLoader{}
+
+	var out strings.Builder
+	err := loader.WriteConfig(&out, nil, nil, "xml")
+	if expectedMsg := "unknown configuration format: xml"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown configuration format")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestLoadWithPrintConfigFlag(t *testing.T) {
+	t.Setenv("man", mane)
+
+	options := map[string]Option{
+		"man": Option{Mandatory: true, Secret: true},
+		"opt": Option{Default: optd},
+	}
+	var out strings.Builder
+	loader := &MultiLoader{Options: options, PrintConfigKey: "print-config", Output: &out}
+
+	config, origin, err := loader.load([]string{"-print-config", "env"}, sampleFlagsHandler)
+	if !errors.Is(err, ErrExit) {
+		t.Errorf("Expected exit request on printing configuration, got: %v", err)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected values on printing configuration")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+
+	expected := "# Environment\nman=\"<redacted>\"\n# Defaults\nopt=opt:defaults\n"
+	if out.String() != expected {
+		t.Error("Printed configuration doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestLoadWithPrintConfigFlagAndMissingMandatoryError(t *testing.T) {
+	options := map[string]Option{"man": Option{Mandatory: true}, "opt": Option{Default: optd}}
+	var out strings.Builder
+	loader := &MultiLoader{Options: options, PrintConfigKey: "print-config", Output: &out}
+
+	config, origin, err := loader.load([]string{"-print-config", "table"}, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: missing mandatory configurations: man"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for printing configuration with missing mandatory configurations")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected values on printing invalid configuration")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+
+	expected := "" +
+		"KEY  VALUE         ORIGIN\n" +
+		"man                Defaults\n" +
+		"opt  opt:defaults  Defaults\n"
+	if out.String() != expected {
+		t.Error("Printed invalid configuration doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
@@ -0,0 +1,127 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// environ returns the environment as a list of "name=value" strings, from
+// Environ if not nil and from os.Environ otherwise.
+func (l MultiLoader) environ() []string {
+	if l.Environ != nil {
+		return l.Environ()
+	}
+	return os.Environ()
+}
+
+// getenv returns the value of the environment variable with the given
+// name, or an empty string if it is not set.
+func (l MultiLoader) getenv(name string) string {
+	if l.Environ == nil {
+		return os.Getenv(name)
+	}
+
+	var value string
+	for _, entry := range l.Environ() {
+		if k, v, ok := strings.Cut(entry, "="); ok && k == name {
+			value = v
+		}
+	}
+	return value
+}
+
+// envName returns the environment variable for a configuration key. If
+// EnvPrefix is present, every key is upper-cased with dots (.) and dashes
+// (-) replaced by underscores (_), and prefixed with EnvPrefix. Otherwise
+// keys in Groups are upper-cased and replaced in the same way, and other
+// keys are used as is.
+func (l MultiLoader) envName(key string) string {
+	if l.EnvPrefix != "" {
+		return l.EnvPrefix + envify(key)
+	}
+
+	for _, group := range l.Groups {
+		if name, ok := strings.CutPrefix(key, group.Name+"."); ok {
+			if _, ok := group.Options[name]; ok {
+				return envify(key)
+			}
+		}
+	}
+	return key
+}
+
+// envify upper-cases a key and replaces dots (.) and dashes (-) with
+// underscores (_).
+func envify(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// unknownEnv returns a description for each environment variable starting
+// with EnvPrefix that is neither the variable of a configuration key, nor
+// JSONEnv, nor the variable locating a Source, along with the nearest
+// known variable, if any. The descriptions are sorted.
+func (l MultiLoader) unknownEnv() []string {
+	if l.EnvPrefix == "" {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for name := range l.options() {
+		known[l.envName(name)] = true
+	}
+	if l.JSONEnv != "" {
+		known[l.JSONEnv] = true
+	}
+	for _, flag := range l.sourceFlags() {
+		if flag.env != "" {
+			known[flag.env] = true
+		}
+	}
+
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, entry := range l.environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, l.EnvPrefix) || known[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unknown = append(unknown, fmt.Sprintf("%s%s", name, suggestion(name, names)))
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// validateEnvNames returns an error if configuration keys share an
+// environment variable, such as "db.host" and "db-host" under EnvPrefix.
+// The error message reports every shared variable with its keys.
+func (l MultiLoader) validateEnvNames() error {
+	owners := make(map[string][]string)
+	for _, name := range sortedKeys(l.options()) {
+		env := l.envName(name)
+		owners[env] = append(owners[env], name)
+	}
+
+	var clashes []string
+	for env, names := range owners {
+		if len(names) > 1 {
+			clashes = append(clashes, fmt.Sprintf("%s (%s)", env, strings.Join(names, ", ")))
+		}
+	}
+
+	if len(clashes) > 0 {
+		sort.Strings(clashes)
+		return fmt.Errorf("options share environment variables: %s", strings.Join(clashes, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromEnvironmentWithPrefix(t *testing.T) {
+	environ := []string{
+		"MYAPP_PORT=8080",
+		"MYAPP_DB_HOST=localhost",
+		"MYAPP_LOG_LEVEL=debug",
+		"port=ignored",
+		"PATH=/bin",
+	}
+	loader := &MultiLoader{
+		Options:   map[string]Option{"port": Option{}, "log-level": Option{}},
+		Groups:    []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		EnvPrefix: "MYAPP_",
+		Environ:   func() []string { return environ },
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from prefixed environment variables: %s", err)
+	}
+
+	expectedConfig := map[string]string{"port": "8080", "db.host": "localhost", "log-level": "debug"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from prefixed environment variables")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"port": envOrig, "db.host": envOrig, "log-level": envOrig}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from prefixed environment variables")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadWithUnknownEnvWarned(t *testing.T) {
+	environ := []string{
+		"MYAPP_DB_HSOT=localhost",
+		"MYAPP_CONFIG=",
+		"MYAPP_PORT=8080",
+		"MYAPP_SOMETHING_ELSE=1",
+		"OTHER_DB_HSOT=1",
+	}
+	var warnings []string
+	loader := &MultiLoader{
+		Options:    map[string]Option{"port": Option{}},
+		Groups:     []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		JSONEnv:    "MYAPP_CONFIG",
+		EnvPrefix:  "MYAPP_",
+		UnknownEnv: WarnUnknown,
+		Environ:    func() []string { return environ },
+		Warn:       func(warning string) { warnings = append(warnings, warning) },
+	}
+
+	config, _, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with warned unknown environment variables: %s", err)
+	}
+
+	if config["port"] != "8080" {
+		t.Errorf("Unexpected configuration with warned unknown environment variables: %#v", config)
+	}
+
+	expectedWarnings := []string{
+		"unknown environment variables: MYAPP_DB_HSOT (did you mean MYAPP_DB_HOST?)",
+		"unknown environment variables: MYAPP_SOMETHING_ELSE",
+	}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Error("Warnings don't match for unknown environment variables")
+		t.Errorf("\nActual  : %#v", warnings)
+		t.Errorf("\nExpected: %#v", expectedWarnings)
+	}
+}
+
+func TestLoadWithUnknownEnvRejectedError(t *testing.T) {
+	t.Setenv("MYAPP_PROT", "8080")
+	t.Setenv("MYAPP_PORT", "8080")
+
+	loader := &MultiLoader{
+		Options:    map[string]Option{"port": Option{}},
+		EnvPrefix:  "MYAPP_",
+		UnknownEnv: RejectUnknown,
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: unknown environment variables: MYAPP_PROT (did you mean MYAPP_PORT?)"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown environment variables")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for unknown environment variables")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestUnknownEnvWithoutPrefixError(t *testing.T) {
+	loader := &MultiLoader{UnknownEnv: WarnUnknown}
+
+	_, _, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: UnknownEnv needs EnvPrefix"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown environment variable detection without prefix")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestWriteHelpWithEnvPrefix(t *testing.T) {
+	loader := &MultiLoader{Options: map[string]Option{"log-level": Option{}}, EnvPrefix: "MYAPP_"}
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "example"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	if expected := "env: MYAPP_LOG_LEVEL"; !strings.Contains(out.String(), expected) {
+		t.Error("Help doesn't show prefixed environment variable")
+		t.Errorf("\nActual       :\n%s", out.String())
+		t.Errorf("\nExpected part: %s", expected)
+	}
+}
+
+func TestSharedEnvNamesError(t *testing.T) {
+	cases := []struct {
+		loader      MultiLoader
+		expectedMsg string
+	}{
+		{
+			MultiLoader{
+				Options:   map[string]Option{"db.host": Option{}, "db-host": Option{}, "db_host": Option{}, "port": Option{}},
+				EnvPrefix: "MYAPP_",
+			},
+			"conf.Load: options share environment variables: MYAPP_DB_HOST (db-host, db.host, db_host)",
+		},
+		{
+			MultiLoader{
+				Options: map[string]Option{"DB_HOST": Option{}},
+				Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+			},
+			"conf.Load: options share environment variables: DB_HOST (DB_HOST, db.host)",
+		},
+	}
+	for _, c := range cases {
+		config, origin, err := c.loader.load(nil, sampleFlagsHandler)
+		if err == nil || err.Error() != c.expectedMsg {
+			t.Error("Invalid error message for options sharing environment variables")
+			t.Errorf("Actual  : %q", err)
+			t.Errorf("Expected: %q", c.expectedMsg)
+		}
+
+		if len(config) != 0 || len(origin) != 0 {
+			t.Error("Unexpected invalid values for options sharing environment variables")
+			t.Errorf("Config: %#v", config)
+			t.Errorf("Origin: %#v", origin)
+		}
+	}
+}
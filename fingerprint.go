@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// fingerprintMetric is the name of the metric written by
+// WriteFingerprintMetric.
+const fingerprintMetric = "conf_fingerprint_info"
+
+// Fingerprint returns a SHA-256 hash, in hexadecimal, of the configuration
+// returned by Load(). It is the same for the same configuration keys and
+// values, whatever their origin, so that replicas whose configuration
+// drifted apart can be spotted. Values of Secret options are hashed before
+// being included, so that they do not appear in what is hashed. This does
+// not protect them: a secret that can be guessed, such as a short password,
+// can be found by trying candidates against a published fingerprint, as it
+// can for any value. Load logs the fingerprint with the values it decrypted
+// treated as Secret; mark their options Secret for Fingerprint to agree.
+func (l MultiLoader) Fingerprint(config map[string]string) string {
+	return l.fingerprint(config, nil)
+}
+
+// fingerprint returns the Fingerprint of the configuration, hashing the
+// values of the keys in secret along with those of Secret options.
+func (l MultiLoader) fingerprint(config map[string]string, secret map[string]bool) string {
+	options := l.options()
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		value := config[key]
+		if options[key].Secret || secret[key] {
+			sum := sha256.Sum256([]byte(value))
+			value = hex.EncodeToString(sum[:])
+		}
+		fmt.Fprintf(hash, "%d:%s%d:%s", len(key), key, len(value), value)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// WriteFingerprintMetric writes the Fingerprint of the configuration to w as
+// a metric in the Prometheus text format, labelled with the application
+// Name, such as
+//
+//	conf_fingerprint_info{name="app",fingerprint="3b0c..."} 1
+func (l MultiLoader) WriteFingerprintMetric(w io.Writer, config map[string]string) error {
+	_, err := fmt.Fprintf(w,
+		"# HELP %[1]s Fingerprint of the loaded configuration.\n"+
+			"# TYPE %[1]s gauge\n"+
+			"%[1]s{name=\"%[2]s\",fingerprint=\"%[3]s\"} 1\n",
+		fingerprintMetric, labelEscape(l.name()), l.Fingerprint(config))
+	if err != nil {
+		return fmt.Errorf("error writing fingerprint metric: %w", err)
+	}
+
+	return nil
+}
+
+// labelEscape escapes a Prometheus label value.
+func labelEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
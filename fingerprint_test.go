@@ -0,0 +1,76 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintIgnoresOrigin(t *testing.T) {
+	options := map[string]Option{"man": Option{}, "opt": Option{Default: "opt:defaults"}}
+	fromFlags := &MultiLoader{Options: options}
+	fromEnv := &MultiLoader{Options: options, Environ: func() []string { return []string{"man=man:value"} }}
+
+	flagsConfig, _, err := fromFlags.load([]string{"-man", "man:value"}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from flags: %s", err)
+	}
+	envConfig, _, err := fromEnv.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from environment: %s", err)
+	}
+
+	if fromFlags.Fingerprint(flagsConfig) != fromEnv.Fingerprint(envConfig) {
+		t.Errorf("Fingerprints differ for the same configuration: %q and %q",
+			fromFlags.Fingerprint(flagsConfig), fromEnv.Fingerprint(envConfig))
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	loader := MultiLoader{Options: map[string]Option{"a": Option{}, "ab": Option{}, "password": Option{Secret: true}}}
+	config := map[string]string{"a": "b", "ab": "", "password": "hunter2"}
+	fingerprint := loader.Fingerprint(config)
+
+	if len(fingerprint) != 64 || strings.Trim(fingerprint, "0123456789abcdef") != "" {
+		t.Errorf("Fingerprint is not a hexadecimal SHA-256 hash: %q", fingerprint)
+	}
+
+	if loader.Fingerprint(config) != fingerprint {
+		t.Error("Fingerprint differs for the same configuration")
+	}
+
+	changes := []map[string]string{
+		{"a": "", "ab": "b", "password": "hunter2"},
+		{"a": "b", "ab": "", "password": "hunter3"},
+		{"a": "b", "ab": ""},
+	}
+	for _, changed := range changes {
+		if loader.Fingerprint(changed) == fingerprint {
+			t.Errorf("Fingerprint is the same for a different configuration: %#v", changed)
+		}
+	}
+
+	plain := MultiLoader{Options: map[string]Option{"a": Option{}, "ab": Option{}, "password": Option{}}}
+	if plain.Fingerprint(config) == fingerprint {
+		t.Error("Fingerprint is the same whether or not secret values are hashed")
+	}
+}
+
+func TestWriteFingerprintMetric(t *testing.T) {
+	loader := MultiLoader{Options: map[string]Option{"man": Option{}}, Name: `my "app"`}
+	config := map[string]string{"man": "man:value"}
+
+	var out strings.Builder
+	if err := loader.WriteFingerprintMetric(&out, config); err != nil {
+		t.Fatalf("Unexpected error writing fingerprint metric: %s", err)
+	}
+
+	expected := `# HELP conf_fingerprint_info Fingerprint of the loaded configuration.
+# TYPE conf_fingerprint_info gauge
+conf_fingerprint_info{name="my \"app\"",fingerprint="` + loader.Fingerprint(config) + `"} 1
+`
+	if out.String() != expected {
+		t.Error("Fingerprint metric doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
@@ -3,4 +3,8 @@
 set -euo pipefail
 IFS=$'\n\t'
 
-go test -v . -timeout 1h -tags fuzz
+FUZZ_TIME="${FUZZ_TIME:-10m}"
+
+for target in FuzzParseFlags FuzzParseJSON FuzzLoad; do
+  go test . -run '^$' -fuzz "^${target}\$" -fuzztime "${FUZZ_TIME}"
+done
@@ -0,0 +1,150 @@
+package conf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fuzzOptions returns options for the configuration keys in keys, one per
+// line, with the default values in defaults, one per line in the same
+// order. The i-th key is mandatory if the i-th bit of mandatory is set.
+func fuzzOptions(keys string, defaults string, mandatory uint64) map[string]Option {
+	defaultValues := strings.Split(defaults, "\n")
+	options := make(map[string]Option)
+	for i, key := range strings.Split(keys, "\n") {
+		var option Option
+		if i < len(defaultValues) {
+			option.Default = defaultValues[i]
+		}
+		option.Mandatory = i < 64 && mandatory&(1<<i) != 0
+		options[key] = option
+	}
+	return options
+}
+
+// fuzzLines splits text into lines, or returns nil for empty text.
+func fuzzLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func FuzzParseFlags(f *testing.F) {
+	f.Add("man\nopt", "-man\nman:flags\n-opt=opt:flags")
+	f.Add("man", "-conf\nfile.json\n-man")
+	f.Add("a=b", "-a=b")
+	f.Add("-man", "--man\nvalue")
+	f.Add("", "-\n--\n-=")
+
+	f.Fuzz(func(t *testing.T, keys string, args string) {
+		loader := MultiLoader{Options: fuzzOptions(keys, "", 0), JSONKey: "conf"}
+		if err := loader.validate(); err != nil {
+			return
+		}
+
+		flagVals, jsonFiles, err := loader.parseFlags(fuzzLines(args), sampleFlagsHandler)
+		if err != nil {
+			if flagVals != nil || jsonFiles != nil {
+				t.Errorf("Error %q with non-empty output: %#v and %#v", err, flagVals, jsonFiles)
+			}
+			return
+		}
+
+		for key := range loader.Options {
+			if flagVals[key] == nil {
+				t.Errorf("No flag value for configuration key %q", key)
+			}
+		}
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	f.Add([]byte(`{ "man": "man:json", "opt": "opt:json" }`))
+	f.Add([]byte(`{ "db": { "host": "localhost", "port": "5432" } }`))
+	f.Add([]byte(`{ "db.host": "flat", "db": { "host": "nested" } }`))
+	f.Add([]byte(`{ "port": 8080 }`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{`))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		config, err := decodeJSON(content)
+		if err != nil {
+			if config != nil {
+				t.Errorf("Error %q with non-empty output: %#v", err, config)
+			}
+			if !strings.HasPrefix(err.Error(), "json: ") {
+				t.Errorf("Error is not described as a JSON error: %q", err)
+			}
+			return
+		}
+
+		var flat map[string]string
+		if json.Unmarshal(content, &flat) == nil && !reflect.DeepEqual(config, flat) {
+			t.Errorf("Configurations don't match plain JSON decoding: %#v and %#v", config, flat)
+		}
+	})
+}
+
+func FuzzLoad(f *testing.F) {
+	f.Add("man\nopt", "\nopt:defaults", uint64(1), "conf", "-man\nman:flags", `{ "opt": "opt:json" }`, "opt=opt:env")
+	f.Add("man\nopt\ndb.host", "man:defaults", uint64(7), "conf", "", `{ "db": { "host": "h" } }`, "man=man:env")
+	f.Add("man", "", uint64(1), "", "", "", "")
+	f.Add("man", "", uint64(0), "man", "-man\nx", "{}", "")
+	f.Add("a=b\n-c", "", uint64(0), "conf", "-a=b", "", "")
+	f.Add("man", "", uint64(0), "conf", "-help", "[]", "man")
+
+	f.Fuzz(func(t *testing.T, keys string, defaults string, mandatory uint64, jsonKey string, args string, jsonContent string, environ string) {
+		options := fuzzOptions(keys, defaults, mandatory)
+		loader := MultiLoader{
+			Options: options,
+			JSONKey: jsonKey,
+			Environ: func() []string { return fuzzLines(environ) },
+		}
+
+		argList := fuzzLines(args)
+		var jsonFile string
+		if jsonKey != "" && jsonContent != "" {
+			jsonFile = filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(jsonFile, []byte(jsonContent), 0o644); err != nil {
+				t.Fatalf("Unexpected error writing JSON file: %s", err)
+			}
+			argList = append(argList, "-"+jsonKey, jsonFile)
+		}
+
+		config, origin, err := loader.load(argList, sampleFlagsHandler)
+		if err != nil {
+			if len(config) > 0 || len(origin) > 0 {
+				t.Errorf("Error %q with non-empty output: %#v and %#v", err, config, origin)
+			}
+			return
+		}
+
+		if len(config) != len(options) || len(origin) != len(options) {
+			t.Errorf("Outputs don't match options: %#v and %#v for %#v", config, origin, options)
+		}
+		for key, option := range options {
+			from, ok := origin[key]
+			if !ok {
+				t.Errorf("No origin for configuration key %q", key)
+				continue
+			}
+			switch {
+			case from == OriginFlags, from == OriginEnvironment, from == OriginJSON+jsonFile && jsonFile != "":
+			case from == OriginDefaults:
+				if config[key] != option.Default {
+					t.Errorf("Configuration %q from defaults is %q, expected: %q", key, config[key], option.Default)
+				}
+			default:
+				t.Errorf("Unexpected origin of configuration key %q: %q", key, from)
+			}
+			if option.Mandatory && config[key] == "" {
+				t.Errorf("Mandatory configuration key %q is empty", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats of configuration files rendered by GenerateConfig.
+const (
+	// FormatJSON is a JSON object of configuration keys to string values,
+	// as read from the file named by JSONKey.
+	FormatJSON = "json"
+
+	// FormatEnv is a list of KEY=value lines, as read by shells and most
+	// container runtimes.
+	FormatEnv = "env"
+)
+
+// GenerateConfig writes a sample configuration file in the given format
+// to w. Every configuration key is included with its Default value, except
+// for Secret options, which are left empty. FormatEnv precedes each key
+// with a comment holding its Desc and marking it if Mandatory, and uses
+// environment variable names. JSON does not allow comments, so FormatJSON
+// holds only the keys and values, with Groups as nested objects; mandatory
+// keys without a Default are left empty to be filled in.
+func (l MultiLoader) GenerateConfig(w io.Writer, format string) error {
+	var err error
+	switch format {
+	case FormatJSON:
+		err = l.generateJSON(w)
+	case FormatEnv:
+		err = l.generateEnv(w)
+	default:
+		return fmt.Errorf("unknown configuration format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s configuration: %w", format, err)
+	}
+
+	return nil
+}
+
+// generateJSON writes a sample JSON configuration file to w.
+func (l MultiLoader) generateJSON(w io.Writer) error {
+	sample := make(map[string]any)
+	for name, option := range l.Options {
+		sample[name] = sampleValue(option)
+	}
+	for _, registration := range l.registrations {
+		for name, option := range registration.options {
+			sample[name] = sampleValue(option)
+		}
+	}
+	for _, group := range l.Groups {
+		nested := make(map[string]string)
+		for name, option := range group.Options {
+			nested[name] = sampleValue(option)
+		}
+		sample[group.Name] = nested
+	}
+
+	return encodeJSON(w, sample)
+}
+
+// encodeJSON writes v to w as indented JSON, without escaping HTML
+// characters.
+func encodeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// generateEnv writes a sample environment file to w.
+func (l MultiLoader) generateEnv(w io.Writer) error {
+	var b strings.Builder
+	if l.Usage != "" {
+		fmt.Fprintf(&b, "# %s\n\n", l.Usage)
+	}
+
+	options := l.options()
+	for i, name := range sortedKeys(options) {
+		option := options[name]
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		comment := name
+		if option.Desc != "" {
+			comment += ": " + option.Desc
+		}
+		if option.Mandatory {
+			comment += " (mandatory)"
+		}
+		fmt.Fprintf(&b, "# %s\n%s=%s\n", comment, l.envName(name), envQuote(sampleValue(option)))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sampleValue returns the value of an option in a sample configuration
+// file, which is its Default unless the option is Secret.
+func sampleValue(option Option) string {
+	if option.Secret {
+		return ""
+	}
+	return option.Default
+}
+
+// envQuote quotes a value for an environment file, unless it is made up
+// only of characters that need no quoting.
+func envQuote(value string) string {
+	for _, r := range value {
+		if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/@%+", r) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
+
+// sortedKeys returns the keys of options in ascending order.
+func sortedKeys(options map[string]Option) []string {
+	keys := make([]string, 0, len(options))
+	for name := range options {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
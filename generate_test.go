@@ -0,0 +1,155 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateConfigJSON(t *testing.T) {
+	options := map[string]Option{
+		"foo": Option{Desc: "a description for foo", Default: "default foo", Mandatory: true},
+		"bar": Option{Mandatory: true},
+		"baz": Option{Desc: "a description for baz"},
+		"pw":  Option{Default: "hunter2", Secret: true},
+	}
+	loader := &MultiLoader{Options: options}
+
+	var out strings.Builder
+	if err := loader.GenerateConfig(&out, FormatJSON); err != nil {
+		t.Fatalf("Unexpected error generating JSON configuration: %s", err)
+	}
+
+	expected := `{
+  "bar": "",
+  "baz": "",
+  "foo": "default foo",
+  "pw": ""
+}
+`
+	if out.String() != expected {
+		t.Error("Generated JSON configuration doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestGenerateConfigEnv(t *testing.T) {
+	options := map[string]Option{
+		"foo": Option{Desc: "a description for foo", Default: "default foo", Mandatory: true},
+		"bar": Option{Mandatory: true},
+		"baz": Option{Desc: "a description for baz", Default: "http://localhost:80"},
+		"pw":  Option{Default: "hunter2", Secret: true},
+	}
+	loader := &MultiLoader{Options: options, Usage: "Example application"}
+
+	var out strings.Builder
+	if err := loader.GenerateConfig(&out, FormatEnv); err != nil {
+		t.Fatalf("Unexpected error generating environment configuration: %s", err)
+	}
+
+	expected := `# Example application
+
+# bar (mandatory)
+bar=
+
+# baz: a description for baz
+baz=http://localhost:80
+
+# foo: a description for foo (mandatory)
+foo="default foo"
+
+# pw
+pw=
+`
+	if out.String() != expected {
+		t.Error("Generated environment configuration doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestGenerateConfigUnknownFormatError(t *testing.T) {
+	loader := &MultiLoader{}
+
+	var out strings.Builder
+	err := loader.GenerateConfig(&out, "xml")
+	if expectedMsg := "unknown configuration format: xml"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown configuration format")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestLoadWithGenerateConfigFlag(t *testing.T) {
+	options := map[string]Option{
+		"man": Option{Mandatory: true},
+		"opt": Option{Default: optd},
+	}
+	var out strings.Builder
+	loader := &MultiLoader{Options: options, GenerateConfigKey: "generate-config", Output: &out}
+
+	config, origin, err := loader.load([]string{"-generate-config", "json"}, sampleFlagsHandler)
+	if !errors.Is(err, ErrExit) {
+		t.Errorf("Expected exit request on generating configuration, got: %v", err)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected values on generating configuration")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+
+	expected := "{\n  \"man\": \"\",\n  \"opt\": \"opt:defaults\"\n}\n"
+	if out.String() != expected {
+		t.Error("Generated configuration doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestLoadWithGenerateConfigFlagUnknownFormatError(t *testing.T) {
+	var out strings.Builder
+	loader := &MultiLoader{GenerateConfigKey: "generate-config", Output: &out}
+
+	_, _, err := loader.load([]string{"-generate-config", "xml"}, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: unknown configuration format: xml"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown configuration format")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestGenerateConfigKeyClashError(t *testing.T) {
+	options := map[string]Option{"conf": Option{}}
+
+	cases := []struct {
+		loader      MultiLoader
+		expectedMsg string
+	}{
+		{
+			MultiLoader{GenerateConfigKey: "gen=1"},
+			"conf.Load: GenerateConfigKey cannot contain '=': gen=1",
+		},
+		{
+			MultiLoader{GenerateConfigKey: "-gen"},
+			"conf.Load: GenerateConfigKey cannot start with '-': -gen",
+		},
+		{
+			MultiLoader{Options: options, GenerateConfigKey: "conf"},
+			"conf.Load: GenerateConfigKey is also an option: conf",
+		},
+		{
+			MultiLoader{JSONKey: "gen", GenerateConfigKey: "gen"},
+			"conf.Load: GenerateConfigKey is the same as JSONKey: gen",
+		},
+	}
+	for _, c := range cases {
+		_, _, err := c.loader.load(nil, sampleFlagsHandler)
+		if err == nil || err.Error() != c.expectedMsg {
+			t.Error("Invalid error message for invalid GenerateConfigKey")
+			t.Errorf("Actual  : %q", err)
+			t.Errorf("Expected: %q", c.expectedMsg)
+		}
+	}
+}
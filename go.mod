@@ -1,5 +1,3 @@
 module github.com/chiku/conf
 
 go 1.26
-
-require github.com/google/gofuzz v1.2.0
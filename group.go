@@ -0,0 +1,110 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Group is a named set of options, such as the options of a reusable
+// package composed by the main package. The configuration key of an
+// option in a Group is the group name and the option name joined by a
+// dot (.), such as "db.host" for option "host" in group "db". The same
+// key is used as command-line flag, as in "-db.host", and as path in the
+// JSON configuration file, as in {"db": {"host": "..."}} or
+// {"db.host": "..."}. The environment variable is the key in upper case
+// with dots (.) and dashes (-) replaced by underscores (_), as in DB_HOST.
+type Group struct {
+	// Name is the prefix for the configuration keys of the group.
+	Name string
+
+	// Desc is the description of the group shown in help.
+	Desc string
+
+	// Options is a map of Option for a given configuration key within
+	// the group.
+	Options map[string]Option
+}
+
+// options returns Options along with the options in Groups and
+// registrations, keyed by their configuration keys.
+func (l MultiLoader) options() map[string]Option {
+	if len(l.Groups) == 0 && len(l.registrations) == 0 {
+		return l.Options
+	}
+
+	options := make(map[string]Option, len(l.Options))
+	for name, option := range l.Options {
+		options[name] = option
+	}
+	for _, group := range l.Groups {
+		for name, option := range group.Options {
+			options[group.Name+"."+name] = option
+		}
+	}
+	for _, registration := range l.registrations {
+		for name, option := range registration.options {
+			options[name] = option
+		}
+	}
+
+	return options
+}
+
+// validateDeclarations checks that group names are present, unique, do not
+// contain equals (=), do not start with minus (-) and are not configuration
+// keys. It also checks that configuration keys of Options, Groups and
+// registrations do not clash with each other.
+func (l MultiLoader) validateDeclarations() error {
+	names := make(map[string]bool)
+	for _, group := range l.Groups {
+		switch {
+		case group.Name == "":
+			return errors.New("group name cannot be empty")
+		case strings.Contains(group.Name, "="):
+			return fmt.Errorf("group name cannot contain '=': %s", group.Name)
+		case strings.HasPrefix(group.Name, "-"):
+			return fmt.Errorf("group name cannot start with '-': %s", group.Name)
+		case names[group.Name]:
+			return fmt.Errorf("group name is repeated: %s", group.Name)
+		}
+		names[group.Name] = true
+	}
+
+	owners := make(map[string][]string)
+	for name := range l.Options {
+		owners[name] = append(owners[name], "Options")
+	}
+	for _, group := range l.Groups {
+		for name := range group.Options {
+			key := group.Name + "." + name
+			owners[key] = append(owners[key], "group "+group.Name)
+		}
+	}
+	for _, registration := range l.registrations {
+		for name := range registration.options {
+			owners[name] = append(owners[name], registration.name)
+		}
+	}
+
+	for _, group := range l.Groups {
+		if declaredBy, ok := owners[group.Name]; ok {
+			return fmt.Errorf("group name is also an option in %s: %s", strings.Join(declaredBy, ", "), group.Name)
+		}
+	}
+
+	var clashes []string
+	for key, declaredBy := range owners {
+		if len(declaredBy) > 1 {
+			clashes = append(clashes, fmt.Sprintf("%s (%s)", key, strings.Join(declaredBy, ", ")))
+		}
+	}
+
+	if len(clashes) > 0 {
+		sort.Strings(clashes)
+		return fmt.Errorf("options are declared more than once: %s", strings.Join(clashes, ", "))
+	}
+
+	return nil
+}
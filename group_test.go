@@ -0,0 +1,237 @@
+package conf
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithGroups(t *testing.T) {
+	jsonFile := createFile(t, `{ "db": { "user": "db-user-json" }, "http.host": "http-host-json" }`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	t.Setenv("DB_MAX_CONNS", "10")
+	t.Setenv("db.max-conns", "ignored")
+
+	options := map[string]Option{
+		"name": Option{Mandatory: true},
+	}
+	groups := []Group{
+		{
+			Name: "db",
+			Options: map[string]Option{
+				"host":      Option{Mandatory: true},
+				"user":      Option{},
+				"max-conns": Option{},
+				"timeout":   Option{Default: "5s"},
+			},
+		},
+		{
+			Name: "http",
+			Options: map[string]Option{
+				"host": Option{},
+			},
+		},
+	}
+	loader := &MultiLoader{Options: options, Groups: groups, JSONKey: "conf"}
+
+	config, origin, err := loader.load([]string{"-conf", jsonFile, "-name", "app", "-db.host", "db-host-flag"}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with groups: %s", err)
+	}
+
+	expectedConfig := map[string]string{
+		"name":         "app",
+		"db.host":      "db-host-flag",
+		"db.user":      "db-user-json",
+		"db.max-conns": "10",
+		"db.timeout":   "5s",
+		"http.host":    "http-host-json",
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with groups")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{
+		"name":         flagsOrig,
+		"db.host":      flagsOrig,
+		"db.user":      jsonOrig + ":" + jsonFile,
+		"db.max-conns": envOrig,
+		"db.timeout":   defaultsOrig,
+		"http.host":    jsonOrig + ":" + jsonFile,
+	}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded with groups")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestInvalidGroupsError(t *testing.T) {
+	cases := []struct {
+		loader      MultiLoader
+		expectedMsg string
+	}{
+		{
+			MultiLoader{Groups: []Group{{Name: ""}}},
+			"conf.Load: group name cannot be empty",
+		},
+		{
+			MultiLoader{Groups: []Group{{Name: "d=b"}}},
+			"conf.Load: group name cannot contain '=': d=b",
+		},
+		{
+			MultiLoader{Groups: []Group{{Name: "-db"}}},
+			"conf.Load: group name cannot start with '-': -db",
+		},
+		{
+			MultiLoader{Groups: []Group{{Name: "db"}, {Name: "db"}}},
+			"conf.Load: group name is repeated: db",
+		},
+		{
+			MultiLoader{
+				Options: map[string]Option{"db.host": Option{}, "db.user": Option{}},
+				Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}, "user": Option{}, "port": Option{}}}},
+			},
+			"conf.Load: options are declared more than once: db.host (Options, group db), db.user (Options, group db)",
+		},
+		{
+			MultiLoader{
+				Options: map[string]Option{"db": Option{}},
+				Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+			},
+			"conf.Load: group name is also an option in Options: db",
+		},
+		{
+			MultiLoader{
+				Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+				JSONKey: "db.host",
+			},
+			"conf.Load: JSONKey is also an option: db.host",
+		},
+	}
+	for _, c := range cases {
+		config, origin, err := c.loader.load(nil, sampleFlagsHandler)
+		if err == nil || err.Error() != c.expectedMsg {
+			t.Error("Invalid error message for invalid groups")
+			t.Errorf("Actual  : %q", err)
+			t.Errorf("Expected: %q", c.expectedMsg)
+		}
+
+		if len(config) != 0 || len(origin) != 0 {
+			t.Error("Unexpected invalid values for invalid groups")
+			t.Errorf("Config: %#v", config)
+			t.Errorf("Origin: %#v", origin)
+		}
+	}
+}
+
+func TestWriteHelpWithGroups(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{"name": Option{}},
+		Groups: []Group{
+			{Name: "db", Desc: "database connection", Options: map[string]Option{"host": Option{Mandatory: true}}},
+		},
+		Usage: "Example application",
+	}
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "example"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	expected := `example: Example application
+
+Options:
+  -name
+      env: name
+
+db: database connection
+  -db.host (mandatory)
+      env: DB_HOST
+
+Other options:
+  -help
+      show this help and exit
+`
+	if out.String() != expected {
+		t.Error("Help with groups doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestJSONSchemaWithGroups(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{"name": Option{}},
+		Groups: []Group{
+			{Name: "db", Desc: "database connection", Options: map[string]Option{"host": Option{Default: "localhost"}}},
+		},
+	}
+
+	content, err := loader.JSONSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering JSON schema: %s", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("Unexpected error parsing rendered JSON schema: %s", err)
+	}
+
+	expectedProps := map[string]any{
+		"name": map[string]any{"type": []any{"string", "null"}},
+		"db": map[string]any{
+			"type":        "object",
+			"description": "database connection",
+			"properties": map[string]any{
+				"host": map[string]any{"type": []any{"string", "null"}, "default": "localhost"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(schema["properties"], expectedProps) {
+		t.Error("JSON schema properties with groups don't match")
+		t.Errorf("\nActual  : %#v", schema["properties"])
+		t.Errorf("\nExpected: %#v", expectedProps)
+	}
+}
+
+func TestGenerateConfigWithGroups(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{"name": Option{Default: "app"}},
+		Groups: []Group{
+			{Name: "db", Options: map[string]Option{"host": Option{Default: "localhost"}, "user": Option{Mandatory: true}}},
+		},
+	}
+
+	cases := map[string]string{
+		FormatJSON: `{
+  "db": {
+    "host": "localhost",
+    "user": ""
+  },
+  "name": "app"
+}
+`,
+		FormatEnv: "# db.host\nDB_HOST=localhost\n\n# db.user (mandatory)\nDB_USER=\n\n# name\nname=app\n",
+	}
+	for format, expected := range cases {
+		var out strings.Builder
+		if err := loader.GenerateConfig(&out, format); err != nil {
+			t.Fatalf("Unexpected error generating %s configuration: %s", format, err)
+		}
+		if out.String() != expected {
+			t.Errorf("Generated %s configuration with groups doesn't match", format)
+			t.Errorf("\nActual  : %q", out.String())
+			t.Errorf("\nExpected: %q", expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// defaultHelpWidth is the width help is wrapped to when the terminal
+// width is not known.
+const defaultHelpWidth = 80
+
+// HelpData is the data passed to HelpTemplate when rendering help.
+type HelpData struct {
+	// Program is the name the application is run as.
+	Program string
+
+	// Usage is the description for the application.
+	Usage string
+
+	// Sections are the groups of options shown in help.
+	Sections []HelpSection
+
+	// Width is the terminal width help is wrapped to.
+	Width int
+}
+
+// A HelpSection is a titled group of options shown in help.
+type HelpSection struct {
+	Title   string
+	Desc    string
+	Options []HelpOption
+}
+
+// A HelpOption describes a command-line flag shown in help.
+type HelpOption struct {
+	// Flag is the command-line flag, including the leading minus (-).
+	Flag string
+
+	// Desc is the description of the flag.
+	Desc string
+
+	// Default is the default value. It is redacted for Secret options.
+	Default string
+
+	// Mandatory is true if the configuration must be specified.
+	Mandatory bool
+
+	// Values are the accepted values, if limited by Option.OneOf.
+	Values []string
+
+	// Env is the environment variable for the option, if any.
+	Env string
+
+	// FileKey is the key for the option in the JSON configuration file,
+	// if JSONKey is set.
+	FileKey string
+}
+
+// defaultHelpTemplate renders help when HelpTemplate is empty.
+const defaultHelpTemplate = `{{.Program}}: {{.Usage}}
+{{range .Sections}}
+{{.Title}}:{{if .Desc}} {{.Desc}}{{end}}
+{{range .Options}}  {{.Flag}}{{if .Mandatory}} (mandatory){{end}}
+{{if .Desc}}{{wrap 6 .Desc}}
+{{end}}{{if .Default}}{{wrap 6 (printf "default: %q" .Default)}}
+{{end}}{{if .Values}}{{wrap 6 (printf "values: %s" (join .Values ", "))}}
+{{end}}{{if .Env}}{{wrap 6 (printf "env: %s" .Env)}}
+{{end}}{{if .FileKey}}{{wrap 6 (printf "file key: %s" .FileKey)}}
+{{end}}{{end}}{{end}}`
+
+// WriteHelp writes help for the application, run as program, to w. It uses
+// HelpTemplate if present and a listing of the options grouped into
+// sections otherwise. Each option shows its default value, whether it is
+// mandatory, its environment variable and its key in the JSON
+// configuration file. Text is wrapped to the terminal width given by the
+// COLUMNS environment variable, looked up in Environ if not nil, or 80 if
+// not known.
+func (l MultiLoader) WriteHelp(w io.Writer, program string) error {
+	tmpl, err := l.helpTemplate()
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(w, l.helpData(program)); err != nil {
+		return fmt.Errorf("error writing help: %w", err)
+	}
+
+	return nil
+}
+
+// helpTemplate parses HelpTemplate, or the default template if HelpTemplate
+// is empty.
+func (l MultiLoader) helpTemplate() (*template.Template, error) {
+	text := l.HelpTemplate
+	if text == "" {
+		text = defaultHelpTemplate
+	}
+
+	width := l.helpWidth()
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"wrap": func(indent int, text string) string { return wrap(text, indent, width) },
+	}
+
+	tmpl, err := template.New("help").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing help template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// helpData returns the data rendered in help for the application, run as
+// program. Options are split into mandatory and optional sections, followed
+// by a section for each of Groups and registrations, and one for the
+// built-in flags.
+func (l MultiLoader) helpData(program string) HelpData {
+	var mandatory, optional []HelpOption
+	for _, name := range sortedKeys(l.Options) {
+		help := l.optionHelp(name, l.Options[name])
+		if help.Mandatory {
+			mandatory = append(mandatory, help)
+		} else {
+			optional = append(optional, help)
+		}
+	}
+
+	var sections []HelpSection
+	if len(mandatory) > 0 {
+		sections = append(sections, HelpSection{Title: "Mandatory options", Options: mandatory})
+	}
+	if len(optional) > 0 {
+		sections = append(sections, HelpSection{Title: "Options", Options: optional})
+	}
+	for _, group := range l.Groups {
+		section := HelpSection{Title: group.Name, Desc: group.Desc}
+		for _, name := range sortedKeys(group.Options) {
+			section.Options = append(section.Options, l.optionHelp(group.Name+"."+name, group.Options[name]))
+		}
+		sections = append(sections, section)
+	}
+	for _, registration := range l.registrations {
+		section := HelpSection{Title: registration.name}
+		for _, name := range sortedKeys(registration.options) {
+			section.Options = append(section.Options, l.optionHelp(name, registration.options[name]))
+		}
+		sections = append(sections, section)
+	}
+	sections = append(sections, HelpSection{Title: "Other options", Options: l.builtinHelp()})
+
+	return HelpData{
+		Program:  program,
+		Usage:    l.Usage,
+		Sections: sections,
+		Width:    l.helpWidth(),
+	}
+}
+
+// optionHelp returns help for an option with the given configuration key.
+func (l MultiLoader) optionHelp(key string, option Option) HelpOption {
+	help := HelpOption{
+		Flag:      "-" + key,
+		Desc:      option.Desc,
+		Default:   option.Default,
+		Mandatory: option.Mandatory,
+		Values:    option.enum,
+		Env:       l.envName(key),
+	}
+	if option.Secret && help.Default != "" {
+		help.Default = redacted
+	}
+	if l.JSONKey != "" {
+		help.FileKey = key
+	}
+
+	return help
+}
+
+// builtinHelp returns help for the built-in flags.
+func (l MultiLoader) builtinHelp() []HelpOption {
+	var options []HelpOption
+	if l.JSONKey != "" {
+		options = append(options, HelpOption{
+			Flag:    "-" + l.JSONKey + " <file>",
+			Desc:    "JSON configuration file, repeated to override earlier files",
+			Default: l.JSONDefault,
+			Env:     l.JSONEnv,
+		})
+	}
+	for _, flag := range l.sourceFlags() {
+		if flag.name != "" {
+			options = append(options, HelpOption{
+				Flag:    "-" + flag.name + " <" + flag.value + ">",
+				Desc:    flag.desc,
+				Default: flag.def,
+				Env:     flag.env,
+			})
+		}
+	}
+	if l.GenerateConfigKey != "" {
+		options = append(options, HelpOption{
+			Flag:   "-" + l.GenerateConfigKey + " <format>",
+			Desc:   "print a sample configuration file in the given format and exit",
+			Values: []string{FormatJSON, FormatEnv},
+		})
+	}
+	if l.PrintConfigKey != "" {
+		options = append(options, HelpOption{
+			Flag:   "-" + l.PrintConfigKey + " <format>",
+			Desc:   "print the loaded configuration in the given format and exit",
+			Values: []string{FormatTable, FormatJSON, FormatEnv},
+		})
+	}
+	options = append(options, HelpOption{Flag: "-help", Desc: "show this help and exit"})
+
+	return options
+}
+
+// helpWidth returns the terminal width given by the COLUMNS environment
+// variable, looked up in Environ if not nil, or defaultHelpWidth if not
+// known.
+func (l MultiLoader) helpWidth() int {
+	if width, err := strconv.Atoi(l.getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultHelpWidth
+}
+
+// wrap breaks text into lines no longer than width, each indented by
+// indent spaces. Words longer than a line are not broken. Existing line
+// breaks are preserved.
+func wrap(text string, indent int, width int) string {
+	prefix := strings.Repeat(" ", indent)
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := prefix
+		for _, word := range strings.Fields(paragraph) {
+			if line != prefix && len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = prefix
+			}
+			if line != prefix {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHelp(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+
+	options := map[string]Option{
+		"foo":   Option{Desc: "a description for foo", Default: "default foo", Mandatory: true},
+		"bar":   Option{Mandatory: true},
+		"mode":  Option{Desc: "a long description for mode that needs to be wrapped"}.OneOf("dev", "prod"),
+		"token": Option{Default: "abc", Secret: true},
+	}
+	loader := &MultiLoader{
+		Options:        options,
+		JSONKey:        "conf",
+		PrintConfigKey: "print-config",
+		Usage:          "Example application",
+	}
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "example"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	expected := `example: Example application
+
+Mandatory options:
+  -bar (mandatory)
+      env: bar
+      file key: bar
+  -foo (mandatory)
+      a description for foo
+      default: "default foo"
+      env: foo
+      file key: foo
+
+Options:
+  -mode
+      a long description for mode that
+      needs to be wrapped
+      values: dev, prod
+      env: mode
+      file key: mode
+  -token
+      default: "<redacted>"
+      env: token
+      file key: token
+
+Other options:
+  -conf <file>
+      JSON configuration file, repeated
+      to override earlier files
+  -print-config <format>
+      print the loaded configuration in
+      the given format and exit
+      values: table, json, env
+  -help
+      show this help and exit
+`
+	if out.String() != expected {
+		t.Error("Help doesn't match")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+}
+
+func TestWriteHelpWithTemplate(t *testing.T) {
+	options := map[string]Option{
+		"foo": Option{Desc: "a description for foo"},
+		"bar": Option{Mandatory: true},
+	}
+	loader := &MultiLoader{
+		Options:      options,
+		Usage:        "Example application",
+		HelpTemplate: `{{.Usage}}{{range .Sections}}|{{.Title}}{{range .Options}} {{.Flag}}{{end}}{{end}}`,
+	}
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "example"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	expected := "Example application|Mandatory options -bar|Options -foo|Other options -help"
+	if out.String() != expected {
+		t.Error("Help from template doesn't match")
+		t.Errorf("\nActual  : %q", out.String())
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
+
+func TestHelpTemplateParseError(t *testing.T) {
+	loader := &MultiLoader{HelpTemplate: "{{.Usage"}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: error parsing help template: "; err == nil || !strings.Contains(err.Error(), expectedMsg) {
+		t.Error("Invalid error message for malformed help template")
+		t.Errorf("Actual       : %q", err)
+		t.Errorf("Expected part: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for malformed help template")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	text := "the quick brown fox jumps over the lazy dog\nnew paragraph"
+
+	wrapped := wrap(text, 2, 16)
+	expected := "  the quick\n  brown fox\n  jumps over the\n  lazy dog\n  new paragraph"
+	if wrapped != expected {
+		t.Error("Wrapped text doesn't match")
+		t.Errorf("\nActual  : %q", wrapped)
+		t.Errorf("\nExpected: %q", expected)
+	}
+}
@@ -2,6 +2,7 @@ package conf
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -29,3 +30,20 @@ func createFile(t *testing.T, content string) string {
 
 	return tmpfile.Name()
 }
+
+// writeFile writes content to a file with the given name in dir, and
+// returns its path. The test aborts on failure.
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Unexpected error creating directory: %s", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Unexpected error writing file: %s", err)
+	}
+
+	return path
+}
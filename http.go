@@ -0,0 +1,212 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout bounds fetching from an HTTPSource when its Timeout
+// is not set.
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPSource is a Source that fetches a JSON document from a URL. The
+// document is read as a JSON configuration file, and the origin of its
+// values is "HTTP:<url>". Documents are cached with their ETag, so that an
+// unchanged document is not downloaded again when loading repeatedly. Use
+// a pointer to an HTTPSource in Sources.
+type HTTPSource struct {
+	// URL is the default URL of the JSON document. No document is fetched
+	// if the URL is not given by URLKey, URLEnv or URL.
+	URL string
+
+	// URLKey, if not empty, is the command-line flag giving the URL.
+	URLKey string
+
+	// URLEnv, if not empty, is the environment variable giving the URL
+	// when it is not given with the URLKey flag.
+	URLEnv string
+
+	// Timeout bounds fetching the document. It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// CacheFile, if not empty, is a local file holding the last document
+	// fetched successfully. It is used when the document cannot be
+	// fetched, with the origin "HTTP:<url> (cached)". Failing to write it
+	// is reported as a warning, as with MultiLoader.Warn.
+	CacheFile string
+
+	// Client is the HTTP client used to fetch the document. It defaults
+	// to http.DefaultClient.
+	Client *http.Client
+
+	mu       sync.Mutex
+	location string
+	etagURL  string
+	etag     string
+	content  []byte
+}
+
+// Fetch fetches the JSON document and returns its values for the given
+// configuration keys. It falls back to CacheFile, if present, when the
+// document cannot be fetched or is not valid, unless ctx is done.
+func (s *HTTPSource) Fetch(ctx context.Context, keys []string) (values map[string]string, origin map[string]string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url := s.location
+	if url == "" {
+		url = s.URL
+	}
+	if url == "" {
+		return nil, nil, nil
+	}
+
+	from := "HTTP:" + url
+	document, err := s.fetch(ctx, url)
+	if err != nil {
+		if s.CacheFile == "" || ctx.Err() != nil {
+			return nil, nil, err
+		}
+		content, cacheErr := os.ReadFile(s.CacheFile)
+		if cacheErr != nil {
+			return nil, nil, fmt.Errorf("%w, and error reading cache file: %w", err, cacheErr)
+		}
+		document, cacheErr = decodeJSON(content)
+		if cacheErr != nil {
+			return nil, nil, fmt.Errorf("%w, and error reading cache file %s: %w", err, s.CacheFile, cacheErr)
+		}
+		from += " (cached)"
+	}
+
+	values = make(map[string]string)
+	origin = make(map[string]string)
+	for _, key := range keys {
+		if value, ok := document[key]; ok {
+			values[key] = value
+			origin[key] = from
+		}
+	}
+
+	return values, origin, nil
+}
+
+// fetch downloads and decodes the JSON document at url, reusing the
+// previous document if the server reports it is unchanged. A document
+// downloaded successfully is remembered with its ETag and written to
+// CacheFile. Failing to write CacheFile is reported as a warning, as the
+// cache is only a fallback.
+func (s *HTTPSource) fetch(ctx context.Context, url string) (map[string]string, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.etag != "" && s.etagURL == url {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && s.etag != "" && s.etagURL == url {
+		return decodeJSON(s.content)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	document, err := decodeJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	s.etagURL, s.etag, s.content = url, resp.Header.Get("ETag"), content
+	if s.CacheFile != "" {
+		if err := writeCacheFile(s.CacheFile, content); err != nil {
+			sourceWarn(ctx)(fmt.Sprintf("HTTP:%s: %s", url, err), "source", "HTTP:"+url)
+		}
+	}
+
+	return document, nil
+}
+
+// String returns "HTTP:<url>", naming the source in errors.
+func (s *HTTPSource) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.location != "" {
+		return "HTTP:" + s.location
+	}
+	return "HTTP:" + s.URL
+}
+
+// sourceFlag describes the URLKey flag.
+func (s *HTTPSource) sourceFlag() sourceFlag {
+	return sourceFlag{
+		field: "HTTPSource.URLKey",
+		name:  s.URLKey,
+		value: "url",
+		desc:  "URL of a JSON configuration document",
+		def:   s.URL,
+		env:   s.URLEnv,
+	}
+}
+
+// locate sets the URL from the URLKey flag, or else from URLEnv. URL is
+// used if neither is given.
+func (s *HTTPSource) locate(flagValue string, getenv func(name string) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.location = flagValue
+	if s.location == "" && s.URLEnv != "" {
+		s.location = getenv(s.URLEnv)
+	}
+}
+
+// writeCacheFile replaces the content of a cache file, writing to a
+// temporary file first so that readers never see a partial file.
+func writeCacheFile(name string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+
+	_, err = tmp.Write(content)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package conf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveJSON returns a test server responding with content and the given
+// ETag, which answers requests matching the ETag with 304 Not Modified.
+// It counts the requests served with content and with 304.
+func serveJSON(t *testing.T, content string, etag string, served *atomic.Int32, notModified *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served.Add(1)
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestLoadFromHTTPSource(t *testing.T) {
+	var served, notModified atomic.Int32
+	server := serveJSON(t, `{ "man": "man:http", "opt": "opt:http", "db": { "host": "host:http" }, "other": "x" }`, "", &served, &notModified)
+	jsonFile := createFile(t, `{ "man": "man:json" }`)
+	defer os.Remove(jsonFile)
+
+	loader := &MultiLoader{
+		Options: map[string]Option{
+			"man": Option{Mandatory: true},
+			"opt": Option{Default: "opt:default"},
+			"ok":  Option{Default: "ok:default"},
+		},
+		Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		JSONKey: "conf",
+		Sources: []Source{&HTTPSource{URL: server.URL}},
+		Environ: func() []string { return []string{"opt=opt:env"} },
+	}
+
+	config, origin, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from HTTP source: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:json", "opt": "opt:http", "ok": "ok:default", "db.host": "host:http"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from HTTP source")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	httpOrig := "HTTP:" + server.URL
+	expectedOrigin := map[string]string{"man": jsonOrig + ":" + jsonFile, "opt": httpOrig, "ok": defaultsOrig, "db.host": httpOrig}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from HTTP source")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadFromHTTPSourceURLFromFlagOrEnvironment(t *testing.T) {
+	var served, notModified atomic.Int32
+	flagServer := serveJSON(t, `{ "man": "man:flag" }`, "", &served, &notModified)
+	envServer := serveJSON(t, `{ "man": "man:env" }`, "", &served, &notModified)
+	defaultServer := serveJSON(t, `{ "man": "man:default" }`, "", &served, &notModified)
+
+	source := &HTTPSource{URL: defaultServer.URL, URLKey: "config-url", URLEnv: "CONFIG_URL"}
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}},
+		Sources: []Source{source},
+	}
+
+	tests := []struct {
+		args     []string
+		environ  []string
+		expected string
+	}{
+		{[]string{"-config-url", flagServer.URL}, []string{"CONFIG_URL=" + envServer.URL}, "man:flag"},
+		{nil, []string{"CONFIG_URL=" + envServer.URL}, "man:env"},
+		{nil, nil, "man:default"},
+	}
+	for _, test := range tests {
+		loader.Environ = func() []string { return test.environ }
+		config, _, err := loader.load(test.args, sampleFlagsHandler)
+		if err != nil {
+			t.Fatalf("Unexpected error loading configurations from HTTP source with %v: %s", test.args, err)
+		}
+		if config["man"] != test.expected {
+			t.Errorf("Unexpected configuration with %v and %v: %q, expected: %q", test.args, test.environ, config["man"], test.expected)
+		}
+	}
+}
+
+func TestLoadFromHTTPSourceReusesDocumentWithETag(t *testing.T) {
+	var served, notModified atomic.Int32
+	server := serveJSON(t, `{ "man": "man:http" }`, `"v1"`, &served, &notModified)
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}},
+		Sources: []Source{&HTTPSource{URL: server.URL}},
+	}
+
+	for i := 0; i < 3; i++ {
+		config, _, err := loader.load(nil, sampleFlagsHandler)
+		if err != nil {
+			t.Fatalf("Unexpected error loading configurations from HTTP source: %s", err)
+		}
+		if config["man"] != "man:http" {
+			t.Errorf("Unexpected configuration: %q, expected: %q", config["man"], "man:http")
+		}
+	}
+
+	if served.Load() != 1 || notModified.Load() != 2 {
+		t.Errorf("Unexpected requests: %d served and %d not modified, expected: 1 and 2", served.Load(), notModified.Load())
+	}
+}
+
+func TestLoadFromHTTPSourceFallsBackToCacheFile(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{ "man": "man:http" }`))
+	}))
+	defer server.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}},
+		Sources: []Source{&HTTPSource{URL: server.URL, CacheFile: cacheFile}},
+	}
+
+	if _, _, err := loader.load(nil, sampleFlagsHandler); err != nil {
+		t.Fatalf("Unexpected error loading configurations from HTTP source: %s", err)
+	}
+
+	failing.Store(true)
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from HTTP cache file: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:http"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from HTTP cache file")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"man": "HTTP:" + server.URL + " (cached)"}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from HTTP cache file")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadFromHTTPSourceWithUnwritableCacheFileWarned(t *testing.T) {
+	var served, notModified atomic.Int32
+	server := serveJSON(t, `{ "man": "man:http" }`, "", &served, &notModified)
+
+	cacheFile := filepath.Join(t.TempDir(), "missing", "cache.json")
+	var warnings []string
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}},
+		Sources: []Source{&HTTPSource{URL: server.URL, CacheFile: cacheFile}},
+		Warn:    func(warning string) { warnings = append(warnings, warning) },
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from HTTP source with unwritable cache file: %s", err)
+	}
+
+	if config["man"] != "man:http" || origin["man"] != "HTTP:"+server.URL {
+		t.Errorf("Unexpected configuration from HTTP source with unwritable cache file: %q from %q", config["man"], origin["man"])
+	}
+
+	expectedWarning := "HTTP:" + server.URL + ": error writing cache file: "
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], expectedWarning) {
+		t.Errorf("Unexpected warnings: %#v, expected prefix: %q", warnings, expectedWarning)
+	}
+}
+
+func TestLoadFromHTTPSourceInvalidJSONError(t *testing.T) {
+	var served, notModified atomic.Int32
+	server := serveJSON(t, `{ "man"`, "", &served, &notModified)
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}},
+		Sources: []Source{&HTTPSource{URL: server.URL}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	expectedMessage := "conf.Load: error reading HTTP:" + server.URL + ": json: syntax error at offset 7: unexpected end of JSON input"
+	if err == nil || err.Error() != expectedMessage {
+		t.Errorf("Unexpected error: %v, expected: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestLoadFromHTTPSourceStatusError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}},
+		Sources: []Source{&HTTPSource{URL: server.URL, CacheFile: filepath.Join(t.TempDir(), "missing.json")}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err == nil {
+		t.Fatal("Expected error loading configurations from failing HTTP source")
+	}
+
+	expectedMessage := "conf.Load: error reading HTTP:" + server.URL + ": unexpected status: 404 Not Found, and error reading cache file"
+	if !strings.HasPrefix(err.Error(), expectedMessage) {
+		t.Errorf("Unexpected error message: %q, expected prefix: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestLoadFromHTTPSourceTimeoutError(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}},
+		Sources: []Source{&HTTPSource{URL: server.URL, Timeout: 10 * time.Millisecond}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err == nil {
+		t.Fatal("Expected error loading configurations from slow HTTP source")
+	}
+
+	expectedMessage := "conf.Load: timed out reading HTTP:" + server.URL + ": "
+	if !strings.HasPrefix(err.Error(), expectedMessage) {
+		t.Errorf("Unexpected error message: %q, expected prefix: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestHTTPSourceURLKeyClashError(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{"url": Option{}},
+		Sources: []Source{&HTTPSource{URLKey: "url"}},
+	}
+
+	_, _, err := loader.load(nil, sampleFlagsHandler)
+	expectedMessage := "conf.Load: HTTPSource.URLKey is also an option: url"
+	if err == nil || err.Error() != expectedMessage {
+		t.Errorf("Unexpected error: %v, expected: %q", err, expectedMessage)
+	}
+}
+
+func TestWriteHelpWithHTTPSource(t *testing.T) {
+	loader := MultiLoader{
+		Sources: []Source{&HTTPSource{URL: "http://config.local/app.json", URLKey: "config-url", URLEnv: "CONFIG_URL"}},
+	}
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "app"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	expected := `  -config-url <url>
+      URL of a JSON configuration document
+      default: "http://config.local/app.json"
+      env: CONFIG_URL
+`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("Help does not describe HTTP source flag:\n%s", out.String())
+	}
+}
@@ -0,0 +1,309 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPollInterval is how often a FileStore is checked for changes when
+// watched, if its PollInterval is not set.
+const defaultPollInterval = time.Second
+
+// A KVStore is a key/value store, such as Consul or etcd, holding values
+// under slash-separated paths.
+type KVStore interface {
+	// Get returns the value at path, and false if there is none.
+	Get(ctx context.Context, path string) (value string, ok bool, err error)
+
+	// List returns the values at every path starting with prefix.
+	List(ctx context.Context, prefix string) (values map[string]string, err error)
+
+	// Watch returns a channel that receives a value each time the values
+	// under prefix change. The channel is closed when ctx is done.
+	Watch(ctx context.Context, prefix string) (changes <-chan struct{}, err error)
+}
+
+// KVSource is a Source reading configuration values from a KVStore. The
+// origin of a value is "KV:<path>". Use a pointer to a KVSource in Sources.
+type KVSource struct {
+	// Store holds the configuration values.
+	Store KVStore
+
+	// Prefix is the path under which the configuration values are kept,
+	// such as "config/myapp/".
+	Prefix string
+
+	// Path, if not nil, returns the path in Store for a configuration key.
+	// The path must start with Prefix. It defaults to Prefix followed by
+	// the key with dots (.) replaced by slashes (/), such as
+	// "config/myapp/db/host" for "db.host".
+	Path func(key string) string
+}
+
+// Fetch lists the values under Prefix and returns those at the paths of
+// the given configuration keys.
+func (s *KVSource) Fetch(ctx context.Context, keys []string) (values map[string]string, origin map[string]string, err error) {
+	stored, err := s.Store.List(ctx, s.Prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing key/value store: %w", err)
+	}
+
+	values = make(map[string]string)
+	origin = make(map[string]string)
+	for _, key := range keys {
+		path := s.path(key)
+		if value, ok := stored[path]; ok {
+			values[key] = value
+			origin[key] = "KV:" + path
+		}
+	}
+
+	return values, origin, nil
+}
+
+// String returns "KV:<prefix>", naming the source in errors.
+func (s *KVSource) String() string {
+	return "KV:" + s.Prefix
+}
+
+// Watch returns a channel that receives a value each time the values under
+// Prefix change, so that the application can load its configuration again.
+// The channel is closed when ctx is done.
+func (s *KVSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return s.Store.Watch(ctx, s.Prefix)
+}
+
+// path returns the path in Store for a configuration key.
+func (s *KVSource) path(key string) string {
+	if s.Path != nil {
+		return s.Path(key)
+	}
+	return s.Prefix + strings.ReplaceAll(key, ".", "/")
+}
+
+// MemoryStore is a KVStore held in memory, such as for tests. Create it
+// with NewMemoryStore.
+type MemoryStore struct {
+	mu       sync.Mutex
+	values   map[string]string
+	watchers map[*memoryWatcher]bool
+}
+
+// A memoryWatcher is a watch on the paths of a MemoryStore starting with
+// prefix.
+type memoryWatcher struct {
+	prefix  string
+	changes chan struct{}
+}
+
+// NewMemoryStore returns a MemoryStore holding a copy of values.
+func NewMemoryStore(values map[string]string) *MemoryStore {
+	m := &MemoryStore{values: make(map[string]string), watchers: make(map[*memoryWatcher]bool)}
+	maps.Copy(m.values, values)
+	return m
+}
+
+// Get returns the value at path, and false if there is none.
+func (m *MemoryStore) Get(_ context.Context, path string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.values[path]
+	return value, ok, nil
+}
+
+// List returns the values at every path starting with prefix.
+func (m *MemoryStore) List(_ context.Context, prefix string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make(map[string]string)
+	for path, value := range m.values {
+		if strings.HasPrefix(path, prefix) {
+			values[path] = value
+		}
+	}
+	return values, nil
+}
+
+// Watch returns a channel that receives a value each time Set or Delete
+// changes a path starting with prefix. Changes made while an earlier one
+// is not yet received are coalesced.
+func (m *MemoryStore) Watch(ctx context.Context, prefix string) (<-chan struct{}, error) {
+	watcher := &memoryWatcher{prefix: prefix, changes: make(chan struct{}, 1)}
+
+	m.mu.Lock()
+	m.watchers[watcher] = true
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.watchers, watcher)
+		close(watcher.changes)
+		m.mu.Unlock()
+	}()
+
+	return watcher.changes, nil
+}
+
+// Set sets the value at path.
+func (m *MemoryStore) Set(path string, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[path] = value
+	m.notify(path)
+}
+
+// Delete removes the value at path.
+func (m *MemoryStore) Delete(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.values[path]; ok {
+		delete(m.values, path)
+		m.notify(path)
+	}
+}
+
+// notify tells the watchers of path about a change. The caller must hold
+// the lock.
+func (m *MemoryStore) notify(path string) {
+	for watcher := range m.watchers {
+		if strings.HasPrefix(path, watcher.prefix) {
+			select {
+			case watcher.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// FileStore is a KVStore reading a directory tree with one file for each
+// path, such as a mounted ConfigMap. The value is the content of the file
+// with trailing newlines removed. Files and directories whose names start
+// with a dot (.) are skipped.
+type FileStore struct {
+	// Dir is the root of the directory tree.
+	Dir string
+
+	// PollInterval is how often the directory tree is checked for changes
+	// when watched. It defaults to 1 second.
+	PollInterval time.Duration
+}
+
+// Get returns the content of the file at path, and false if there is none.
+func (f FileStore) Get(_ context.Context, path string) (string, bool, error) {
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", false, fmt.Errorf("path is outside the directory: %s", path)
+	}
+
+	content, err := os.ReadFile(filepath.Join(f.Dir, filepath.FromSlash(path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// List returns the content of every file whose path starts with prefix. It
+// stops when ctx is done.
+func (f FileStore) List(ctx context.Context, prefix string) (map[string]string, error) {
+	values := make(map[string]string)
+	err := filepath.WalkDir(f.Dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name == f.Dir {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.Dir, name)
+		if err != nil {
+			return err
+		}
+		path := filepath.ToSlash(rel)
+		if !strings.HasPrefix(path, prefix) {
+			return nil
+		}
+
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		values[path] = strings.TrimRight(string(content), "\r\n")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Watch returns a channel that receives a value each time the files whose
+// paths start with prefix change, checked every PollInterval. Errors while
+// checking are treated as no change.
+func (f FileStore) Watch(ctx context.Context, prefix string) (<-chan struct{}, error) {
+	last, err := f.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := f.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			values, err := f.List(ctx, prefix)
+			if err != nil || maps.Equal(values, last) {
+				continue
+			}
+			last = values
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes, nil
+}
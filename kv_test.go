@@ -0,0 +1,145 @@
+package conf
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFromKVSource(t *testing.T) {
+	store := NewMemoryStore(map[string]string{
+		"config/app/man":     "man:kv",
+		"config/app/db/host": "host:kv",
+		"config/app/other":   "other:kv",
+		"config/other/opt":   "opt:other",
+	})
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{Mandatory: true}, "opt": Option{Default: "opt:default"}},
+		Groups:  []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		Sources: []Source{&KVSource{Store: store, Prefix: "config/app/"}},
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from key/value store: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:kv", "opt": "opt:default", "db.host": "host:kv"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from key/value store")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"man": "KV:config/app/man", "opt": defaultsOrig, "db.host": "KV:config/app/db/host"}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from key/value store")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadFromKVSourceWithPathMapping(t *testing.T) {
+	store := NewMemoryStore(map[string]string{"app/MAN": "man:kv"})
+	source := &KVSource{
+		Store:  store,
+		Prefix: "app/",
+		Path:   func(key string) string { return "app/" + strings.ToUpper(key) },
+	}
+	loader := &MultiLoader{Options: map[string]Option{"man": Option{Mandatory: true}}, Sources: []Source{source}}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from key/value store: %s", err)
+	}
+
+	if config["man"] != "man:kv" || origin["man"] != "KV:app/MAN" {
+		t.Errorf("Unexpected configuration and origin: %q and %q", config["man"], origin["man"])
+	}
+}
+
+func TestMemoryStoreWatch(t *testing.T) {
+	store := NewMemoryStore(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := (&KVSource{Store: store, Prefix: "app/"}).Watch(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error watching memory store: %s", err)
+	}
+
+	store.Set("other/man", "man:other")
+	select {
+	case <-changes:
+		t.Error("Unexpected change reported for a path outside the prefix")
+	default:
+	}
+
+	store.Set("app/man", "man:kv")
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Error("Expected change reported for a path under the prefix")
+	}
+
+	if value, ok, _ := store.Get(ctx, "app/man"); !ok || value != "man:kv" {
+		t.Errorf("Unexpected value: %q, %t", value, ok)
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "man", "man:file\n")
+	writeFile(t, filepath.Join(dir, "db"), "host", "host:file\r\n")
+	writeFile(t, filepath.Join(dir, "..data"), "man", "hidden")
+	writeFile(t, dir, ".hidden", "hidden")
+	store := FileStore{Dir: dir}
+	ctx := context.Background()
+
+	values, err := store.List(ctx, "")
+	if err != nil {
+		t.Fatalf("Unexpected error listing file store: %s", err)
+	}
+
+	expected := map[string]string{"man": "man:file", "db/host": "host:file"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Error("Values don't match when listed from file store")
+		t.Errorf("\nActual  : %#v", values)
+		t.Errorf("\nExpected: %#v", expected)
+	}
+
+	if value, ok, err := store.Get(ctx, "db/host"); err != nil || !ok || value != "host:file" {
+		t.Errorf("Unexpected value: %q, %t, %v", value, ok, err)
+	}
+	if _, ok, err := store.Get(ctx, "db/port"); err != nil || ok {
+		t.Errorf("Unexpected value for missing path: %t, %v", ok, err)
+	}
+	if _, _, err := store.Get(ctx, "../secret"); err == nil {
+		t.Error("Expected error getting a path outside the directory")
+	}
+}
+
+func TestFileStoreWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "man", "man:file")
+	store := FileStore{Dir: dir, PollInterval: 5 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := store.Watch(ctx, "")
+	if err != nil {
+		t.Fatalf("Unexpected error watching file store: %s", err)
+	}
+
+	writeFile(t, dir, "man", "man:changed")
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Error("Expected change reported for a changed file")
+	}
+}
@@ -0,0 +1,72 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// log sends an event to Logger, if present.
+func (l MultiLoader) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if l.Logger != nil {
+		l.Logger.Log(ctx, level, msg, args...)
+	}
+}
+
+// logFiles logs the JSON files selected and how they were selected, if
+// there are any.
+func (l MultiLoader) logFiles(ctx context.Context, files []string, selectedBy string) {
+	if len(files) > 0 {
+		l.log(ctx, slog.LevelInfo, "configuration files selected", "files", files, "selected_by", selectedBy)
+	}
+}
+
+// logSource logs that a source was consulted and the number of values
+// found in it.
+func (l MultiLoader) logSource(ctx context.Context, source string, found int) {
+	l.log(ctx, slog.LevelDebug, "configuration source consulted", "source", source, "values", found)
+}
+
+// logOverride logs that the value of a configuration key from origin
+// overrides the one from overridden.
+func (l MultiLoader) logOverride(ctx context.Context, key string, origin string, overridden string) {
+	l.log(ctx, slog.LevelDebug, "configuration overridden", "key", key, "origin", origin, "overridden", overridden)
+}
+
+// logResolved logs each configuration value with its origin, in sorted
+// order of keys, followed by the Fingerprint of the configuration. Values
+// of Secret options and values that were encrypted are redacted, and are
+// hashed alike in the fingerprint.
+func (l MultiLoader) logResolved(
+	ctx context.Context,
+	config map[string]string,
+	origin map[string]string,
+	encrypted map[string]bool,
+) {
+	if l.Logger == nil {
+		return
+	}
+
+	options := l.options()
+	for _, name := range sortedKeys(options) {
+		value := config[name]
+		if value != "" && (options[name].Secret || encrypted[name]) {
+			value = redacted
+		}
+		l.log(ctx, slog.LevelInfo, "configuration resolved", "key", name, "value", value, "origin", origin[name])
+	}
+	l.log(ctx, slog.LevelInfo, "configuration loaded", "fingerprint", l.fingerprint(config, encrypted))
+}
+
+// warnDeprecated warns about each deprecated option set by anything other
+// than its default, in sorted order of keys.
+func (l MultiLoader) warnDeprecated(config map[string]string, origin map[string]string) {
+	options := l.options()
+	for _, name := range sortedKeys(options) {
+		option := options[name]
+		if option.Deprecated == "" || config[name] == "" || origin[name] == OriginDefaults {
+			continue
+		}
+		l.warn(fmt.Sprintf("%s is deprecated: %s", name, option.Deprecated), "key", name, "origin", origin[name])
+	}
+}
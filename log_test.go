@@ -0,0 +1,101 @@
+package conf
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTestLogger returns a logger writing events at all levels as text,
+// without timestamps, to out.
+func newTestLogger(out *strings.Builder) *slog.Logger {
+	return slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+}
+
+func TestLoadWithLogger(t *testing.T) {
+	aesgcm := newTestAESGCM(t, testKey)
+	jsonFile := createFile(t, `{ "password": "hunter2" }`)
+	defer os.Remove(jsonFile)
+
+	var out strings.Builder
+	var warnings []string
+	loader := &MultiLoader{
+		Options: map[string]Option{
+			"mode":     Option{Default: "dev"},
+			"old":      Option{Deprecated: "use mode"},
+			"password": Option{Secret: true},
+			"port":     Option{},
+			"token":    Option{},
+		},
+		JSONKey:   "conf",
+		Sources:   []Source{staticSource{name: "remote", values: map[string]string{"port": "port:remote"}}},
+		Decrypter: aesgcm,
+		Environ: func() []string {
+			return []string{"port=port:env", "old=old:env", "token=" + encryptTestValue(t, aesgcm, "token", "token:env")}
+		},
+		Warn:   func(warning string) { warnings = append(warnings, warning) },
+		Logger: newTestLogger(&out),
+	}
+
+	config, _, err := loader.load([]string{"-port", "port:flags", "-conf", jsonFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with logger: %s", err)
+	}
+
+	expected := strings.Join([]string{
+		`level=INFO msg="configuration files selected" files=[` + jsonFile + `] selected_by=Flags`,
+		`level=DEBUG msg="configuration source consulted" source=Flags values=1`,
+		`level=DEBUG msg="configuration source consulted" source=JSON:` + jsonFile + ` values=1`,
+		`level=DEBUG msg="configuration overridden" key=port origin=Flags overridden=Static:remote`,
+		`level=DEBUG msg="configuration source consulted" source=conf.staticSource values=1`,
+		`level=DEBUG msg="configuration overridden" key=port origin=Flags overridden=Environment`,
+		`level=DEBUG msg="configuration source consulted" source=Environment values=3`,
+		`level=DEBUG msg="configuration source consulted" source=Defaults values=1`,
+		`level=WARN msg="configuration warning" warning="old is deprecated: use mode" key=old origin=Environment`,
+		`level=INFO msg="configuration resolved" key=mode value=dev origin=Defaults`,
+		`level=INFO msg="configuration resolved" key=old value=old:env origin=Environment`,
+		`level=INFO msg="configuration resolved" key=password value=<redacted> origin=JSON:` + jsonFile,
+		`level=INFO msg="configuration resolved" key=port value=port:flags origin=Flags`,
+		`level=INFO msg="configuration resolved" key=token value=<redacted> origin=Environment`,
+		`level=INFO msg="configuration loaded" fingerprint=` + loader.fingerprint(config, map[string]bool{"token": true}),
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Error("Log doesn't match when loaded with logger")
+		t.Errorf("\nActual  :\n%s", out.String())
+		t.Errorf("\nExpected:\n%s", expected)
+	}
+
+	if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "token:env") {
+		t.Errorf("Log leaks secrets:\n%s", out.String())
+	}
+
+	expectedWarnings := []string{"old is deprecated: use mode"}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("Unexpected warnings: %#v, expected: %#v", warnings, expectedWarnings)
+	}
+}
+
+func TestLoadWithDeprecatedDefaultNotWarned(t *testing.T) {
+	var out strings.Builder
+	loader := &MultiLoader{
+		Options: map[string]Option{"old": Option{Default: "old:default", Deprecated: "use new"}},
+		Logger:  newTestLogger(&out),
+	}
+
+	if _, _, err := loader.load(nil, sampleFlagsHandler); err != nil {
+		t.Fatalf("Unexpected error loading configurations with logger: %s", err)
+	}
+
+	if strings.Contains(out.String(), "deprecated") {
+		t.Errorf("Unexpected deprecation warning for a default value:\n%s", out.String())
+	}
+}
@@ -0,0 +1,54 @@
+package conf
+
+// A Registration is a set of options contributed to a MultiLoader by a
+// package, such as a shared HTTP or database package. After a successful
+// Load, the package reads back its own slice of the configuration from the
+// Registration.
+type Registration struct {
+	name    string
+	options map[string]Option
+	config  map[string]string
+	origin  map[string]string
+}
+
+// Register adds options contributed by the named package to the loader.
+// The configuration keys of the options are used as they are, so they must
+// not clash with Options, Groups or other registrations; Load reports
+// clashes along with the names of the packages declaring them. The
+// returned Registration holds the configuration and origin of the
+// options once Load succeeds. A Registration must not be shared by
+// loaders that load concurrently.
+func (l *MultiLoader) Register(name string, options map[string]Option) *Registration {
+	registration := &Registration{name: name, options: options}
+	l.registrations = append(l.registrations, registration)
+	return registration
+}
+
+// Name returns the name of the package that contributed the options.
+func (r *Registration) Name() string {
+	return r.name
+}
+
+// Config returns the configuration for the registered options from the
+// last successful Load. It is nil if Load has not succeeded.
+func (r *Registration) Config() map[string]string {
+	return r.config
+}
+
+// Origin returns the origin of the configuration for the registered
+// options from the last successful Load. It is nil if Load has not
+// succeeded.
+func (r *Registration) Origin() map[string]string {
+	return r.origin
+}
+
+// fill sets the configuration and origin of the registered options from
+// those of all options.
+func (r *Registration) fill(config map[string]string, origin map[string]string) {
+	r.config = make(map[string]string, len(r.options))
+	r.origin = make(map[string]string, len(r.options))
+	for name := range r.options {
+		r.config[name] = config[name]
+		r.origin[name] = origin[name]
+	}
+}
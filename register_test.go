@@ -0,0 +1,116 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithRegistrations(t *testing.T) {
+	t.Setenv("db-url", "postgres://db")
+
+	loader := &MultiLoader{Options: map[string]Option{"name": Option{Default: "app"}}}
+	httpReg := loader.Register("http", map[string]Option{
+		"port": Option{Default: "8080"},
+		"host": Option{},
+	})
+	dbReg := loader.Register("db", map[string]Option{
+		"db-url": Option{Mandatory: true},
+	})
+
+	config, origin, err := loader.load([]string{"-host", "localhost"}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with registrations: %s", err)
+	}
+
+	expectedConfig := map[string]string{
+		"name":   "app",
+		"port":   "8080",
+		"host":   "localhost",
+		"db-url": "postgres://db",
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with registrations")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedHTTPConfig := map[string]string{"port": "8080", "host": "localhost"}
+	if !reflect.DeepEqual(httpReg.Config(), expectedHTTPConfig) {
+		t.Error("Registered configurations don't match")
+		t.Errorf("\nActual  : %#v", httpReg.Config())
+		t.Errorf("\nExpected: %#v", expectedHTTPConfig)
+	}
+
+	expectedHTTPOrigin := map[string]string{"port": defaultsOrig, "host": flagsOrig}
+	if !reflect.DeepEqual(httpReg.Origin(), expectedHTTPOrigin) {
+		t.Error("Registered origins don't match")
+		t.Errorf("\nActual  : %#v", httpReg.Origin())
+		t.Errorf("\nExpected: %#v", expectedHTTPOrigin)
+	}
+
+	expectedDBConfig := map[string]string{"db-url": "postgres://db"}
+	if !reflect.DeepEqual(dbReg.Config(), expectedDBConfig) {
+		t.Error("Registered configurations don't match")
+		t.Errorf("\nActual  : %#v", dbReg.Config())
+		t.Errorf("\nExpected: %#v", expectedDBConfig)
+	}
+
+	if origin["db-url"] != envOrig {
+		t.Errorf("Unexpected origin for registered option: %q", origin["db-url"])
+	}
+}
+
+func TestLoadWithRegistrationsFailure(t *testing.T) {
+	loader := &MultiLoader{}
+	registration := loader.Register("db", map[string]Option{"db-url": Option{Mandatory: true}})
+
+	_, _, err := loader.load(nil, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: missing mandatory configurations: db-url"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for missing registered configurations")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if registration.Config() != nil || registration.Origin() != nil {
+		t.Error("Unexpected registered values after failure")
+		t.Errorf("Config: %#v", registration.Config())
+		t.Errorf("Origin: %#v", registration.Origin())
+	}
+}
+
+func TestRegistrationsClashError(t *testing.T) {
+	loader := &MultiLoader{Options: map[string]Option{"port": Option{}}}
+	loader.Register("http", map[string]Option{"port": Option{}, "host": Option{}})
+	loader.Register("metrics", map[string]Option{"port": Option{}, "host": Option{}, "path": Option{}})
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	expectedMsg := "conf.Load: options are declared more than once: host (http, metrics), port (Options, http, metrics)"
+	if err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for clashing registrations")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for clashing registrations")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestWriteHelpWithRegistrations(t *testing.T) {
+	loader := &MultiLoader{Usage: "Example application"}
+	loader.Register("http", map[string]Option{"port": Option{Desc: "listen port"}})
+
+	var out strings.Builder
+	if err := loader.WriteHelp(&out, "example"); err != nil {
+		t.Fatalf("Unexpected error writing help: %s", err)
+	}
+
+	if expected := "\nhttp:\n  -port\n      listen port\n      env: port\n"; !strings.Contains(out.String(), expected) {
+		t.Error("Help doesn't have section for registration")
+		t.Errorf("\nActual       :\n%s", out.String())
+		t.Errorf("\nExpected part:\n%s", expected)
+	}
+}
@@ -0,0 +1,124 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// schemaDraft is the JSON Schema dialect used by JSONSchema.
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// integerPattern matches the values accepted by Option.Range.
+const integerPattern = "^[+-]?[0-9]+$"
+
+// emptyPattern precedes the pattern of a value, so that an empty value,
+// which Load treats as not set, is accepted too.
+const emptyPattern = "^$|"
+
+// A jsonSchema is the subset of JSON Schema rendered by JSONSchema.
+type jsonSchema struct {
+	Schema               string                    `json:"$schema,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]jsonSchemaProp `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchemaProp           `json:"additionalProperties,omitempty"`
+}
+
+// A jsonSchemaProp describes a single configuration key, or a Group of
+// them, in a jsonSchema. Type is a type name, or a list of them.
+type jsonSchemaProp struct {
+	Type        any                       `json:"type"`
+	Description string                    `json:"description,omitempty"`
+	Default     string                    `json:"default,omitempty"`
+	Enum        []any                     `json:"enum,omitempty"`
+	Pattern     string                    `json:"pattern,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Mandatory   bool                      `json:"x-mandatory,omitempty"`
+	Properties  map[string]jsonSchemaProp `json:"properties,omitempty"`
+}
+
+// JSONSchema renders Options as a JSON Schema document describing the
+// JSON configuration file named by JSONKey. Every value is a string, or
+// null, as expected in the JSON file. Descriptions, defaults and the values
+// accepted by OneOf, Range, URL and Pattern are included. As Load treats an
+// empty or null value as not set, these are accepted along with the values
+// of OneOf, Range and Pattern. An Option constrained by both Range and
+// Pattern is described by its Pattern. Each of Groups is described as a
+// nested object. The defaults of Secret options are left out, as the
+// schema may be published.
+//
+// Mandatory options are marked with "x-mandatory" instead of being listed
+// as "required", because they may also be provided by command-line
+// arguments, environment variables or defaults.
+func (l MultiLoader) JSONSchema() ([]byte, error) {
+	schema := jsonSchema{
+		Schema:               schemaDraft,
+		Description:          l.Usage,
+		Type:                 "object",
+		Properties:           schemaProps(l.Options),
+		AdditionalProperties: &jsonSchemaProp{Type: "string"},
+	}
+
+	for _, registration := range l.registrations {
+		for name, prop := range schemaProps(registration.options) {
+			schema.Properties[name] = prop
+		}
+	}
+	for _, group := range l.Groups {
+		schema.Properties[group.Name] = jsonSchemaProp{
+			Type:        "object",
+			Description: group.Desc,
+			Properties:  schemaProps(group.Options),
+		}
+	}
+
+	content, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("conf.JSONSchema: %w", err)
+	}
+
+	return append(content, '\n'), nil
+}
+
+// schemaProps returns the JSON Schema properties describing options.
+func schemaProps(options map[string]Option) map[string]jsonSchemaProp {
+	props := make(map[string]jsonSchemaProp, len(options))
+	for name, option := range options {
+		prop := jsonSchemaProp{
+			Type:        []string{"string", "null"},
+			Description: option.Desc,
+			Default:     option.Default,
+			Pattern:     option.pattern,
+			Mandatory:   option.Mandatory,
+		}
+		if option.Secret {
+			prop.Default = ""
+		}
+		if len(option.enum) > 0 {
+			prop.Enum = append([]any{"", nil}, stringsToAny(option.enum)...)
+		}
+		switch option.kind {
+		case kindInteger:
+			if prop.Pattern == "" {
+				prop.Pattern = integerPattern
+			}
+		case kindURL:
+			prop.Format = "uri"
+		}
+		if prop.Pattern != "" {
+			prop.Pattern = emptyPattern + prop.Pattern
+		}
+		props[name] = prop
+	}
+
+	return props
+}
+
+// stringsToAny returns values as a list of any.
+func stringsToAny(values []string) []any {
+	list := make([]any, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
@@ -0,0 +1,195 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	options := map[string]Option{
+		"host": Option{Desc: "server host", Default: "localhost", Mandatory: true},
+		"port": Option{Desc: "server port"}.Range(1, 65535),
+		"mode": Option{}.OneOf("dev", "prod"),
+		"api":  Option{}.URL(),
+		"name": Option{}.Range(1, 9).Pattern("[1-9]"),
+		"pw":   Option{Default: "hunter2", Secret: true},
+	}
+	loader := &MultiLoader{Options: options, JSONKey: "conf", Usage: "Example application"}
+
+	content, err := loader.JSONSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering JSON schema: %s", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("Unexpected error parsing rendered JSON schema: %s", err)
+	}
+
+	expectedSchema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"description": "Example application",
+		"type":        "object",
+		"properties": map[string]any{
+			"host": map[string]any{
+				"type":        []any{"string", "null"},
+				"description": "server host",
+				"default":     "localhost",
+				"x-mandatory": true,
+			},
+			"port": map[string]any{
+				"type":        []any{"string", "null"},
+				"description": "server port",
+				"pattern":     "^$|^[+-]?[0-9]+$",
+			},
+			"mode": map[string]any{
+				"type": []any{"string", "null"},
+				"enum": []any{"", nil, "dev", "prod"},
+			},
+			"api": map[string]any{
+				"type":   []any{"string", "null"},
+				"format": "uri",
+			},
+			"name": map[string]any{
+				"type":    []any{"string", "null"},
+				"pattern": "^$|^(?:[1-9])$",
+			},
+			"pw": map[string]any{
+				"type": []any{"string", "null"},
+			},
+		},
+		"additionalProperties": map[string]any{"type": "string"},
+	}
+	if !reflect.DeepEqual(schema, expectedSchema) {
+		t.Error("JSON schema doesn't match")
+		t.Errorf("\nActual  : %#v", schema)
+		t.Errorf("\nExpected: %#v", expectedSchema)
+	}
+}
+
+func TestJSONSchemaIsStable(t *testing.T) {
+	options := map[string]Option{
+		"a": Option{Desc: "a"},
+		"b": Option{Desc: "b"},
+		"c": Option{Desc: "c"},
+	}
+	loader := &MultiLoader{Options: options}
+
+	first, err := loader.JSONSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering JSON schema: %s", err)
+	}
+	for i := 0; i < 10; i++ {
+		next, err := loader.JSONSchema()
+		if err != nil {
+			t.Fatalf("Unexpected error rendering JSON schema: %s", err)
+		}
+		if string(next) != string(first) {
+			t.Fatalf("JSON schema changed between renders:\n%s\n%s", first, next)
+		}
+	}
+}
+
+// checkSchema returns the paths of the values in document not matching the
+// type, enum and pattern of their properties in schema, the subset of JSON
+// Schema rendered by JSONSchema.
+func checkSchema(schema map[string]any, document map[string]any, prefix string) []string {
+	var mismatches []string
+	properties, _ := schema["properties"].(map[string]any)
+	for name, value := range document {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			prop, _ = schema["additionalProperties"].(map[string]any)
+		}
+
+		if nested, ok := value.(map[string]any); ok {
+			if prop["type"] != "object" {
+				mismatches = append(mismatches, prefix+name)
+			}
+			mismatches = append(mismatches, checkSchema(prop, nested, prefix+name+".")...)
+			continue
+		}
+
+		kind := "null"
+		if _, ok := value.(string); ok {
+			kind = "string"
+		}
+		types, ok := prop["type"].([]any)
+		if !ok {
+			types = []any{prop["type"]}
+		}
+		matches := false
+		for _, t := range types {
+			matches = matches || t == kind
+		}
+
+		if enum, ok := prop["enum"].([]any); ok {
+			found := false
+			for _, allowed := range enum {
+				found = found || allowed == value
+			}
+			matches = matches && found
+		}
+
+		if pattern, ok := prop["pattern"].(string); ok && kind == "string" {
+			matches = matches && regexp.MustCompile(pattern).MatchString(value.(string))
+		}
+
+		if !matches {
+			mismatches = append(mismatches, prefix+name)
+		}
+	}
+
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+func TestGeneratedConfigMatchesJSONSchema(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{
+			"host": Option{Default: "localhost", Mandatory: true},
+			"port": Option{}.Range(1, 65535),
+			"mode": Option{}.OneOf("dev", "prod"),
+			"api":  Option{}.URL(),
+			"name": Option{}.Pattern("[a-z]+"),
+		},
+		Groups: []Group{{Name: "db", Options: map[string]Option{"pool": Option{Default: "10"}.Range(1, 100)}}},
+	}
+
+	content, err := loader.JSONSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering JSON schema: %s", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("Unexpected error parsing rendered JSON schema: %s", err)
+	}
+
+	var sample strings.Builder
+	if err := loader.GenerateConfig(&sample, FormatJSON); err != nil {
+		t.Fatalf("Unexpected error generating JSON configuration: %s", err)
+	}
+	var document map[string]any
+	if err := json.Unmarshal([]byte(sample.String()), &document); err != nil {
+		t.Fatalf("Unexpected error parsing generated JSON configuration: %s", err)
+	}
+
+	if mismatches := checkSchema(schema, document, ""); len(mismatches) > 0 {
+		t.Errorf("Generated configuration doesn't match JSON schema at %v:\n%s", mismatches, sample.String())
+	}
+
+	nulls := map[string]any{"mode": nil, "port": nil, "name": "", "db": map[string]any{"pool": nil}}
+	if mismatches := checkSchema(schema, nulls, ""); len(mismatches) > 0 {
+		t.Errorf("Empty and null values don't match JSON schema at %v", mismatches)
+	}
+
+	invalid := map[string]any{"mode": "test", "port": "http", "name": "A1", "db": map[string]any{"pool": "x"}}
+	expected := []string{"db.pool", "mode", "name", "port"}
+	if mismatches := checkSchema(schema, invalid, ""); !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("Invalid values matching JSON schema: %v, expected mismatches: %v", mismatches, expected)
+	}
+}
@@ -0,0 +1,208 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// A Source provides configuration values from somewhere other than
+// command-line flags, JSON files, environment variables and defaults, such
+// as a remote service. Sources are consulted by Load() after the JSON files
+// and before environment variables. A Source may implement fmt.Stringer to
+// be named in errors.
+type Source interface {
+	// Fetch returns the values for the given configuration keys and the
+	// origin of each value. Keys without a value may be left out. Fetch
+	// should give up when ctx is done.
+	Fetch(ctx context.Context, keys []string) (values map[string]string, origin map[string]string, err error)
+}
+
+// A flagSource is a Source whose location may be given by a command-line
+// flag or an environment variable.
+type flagSource interface {
+	Source
+
+	// sourceFlag describes the command-line flag of the Source. No flag is
+	// registered if its name is empty.
+	sourceFlag() sourceFlag
+
+	// locate sets the location of the Source from the value of its flag,
+	// or else from its environment variable looked up with getenv.
+	locate(flagValue string, getenv func(name string) string)
+}
+
+// A sourceFlag describes the command-line flag of a flagSource.
+type sourceFlag struct {
+	field string // field of the Source holding the flag name
+	name  string // flag name
+	value string // placeholder for the flag value in help
+	desc  string // flag description
+	def   string // default location
+	env   string // environment variable for the location
+}
+
+// sourceFlags returns the command-line flags of Sources, in order.
+func (l MultiLoader) sourceFlags() []sourceFlag {
+	var flags []sourceFlag
+	for _, source := range l.Sources {
+		if source, ok := source.(flagSource); ok {
+			flags = append(flags, source.sourceFlag())
+		}
+	}
+	return flags
+}
+
+// fetchAll reads the JSON files and fetches the values of every
+// configuration key from each of Sources, all at the same time. Sources are
+// first located from their flag values in flagVals or their environment
+// variables. The results are in the order of jsonFiles and Sources, so that
+// they can be merged by precedence. Every read is attempted, and the errors
+// of all that fail are returned together, in the same order. Warnings of
+// Sources are reported one at a time.
+func (l MultiLoader) fetchAll(
+	ctx context.Context,
+	jsonFiles []string,
+	flagVals map[string]*string,
+) (jsonConfigs []map[string]string, values []map[string]string, origins []map[string]string, err error) {
+	for _, source := range l.Sources {
+		if source, ok := source.(flagSource); ok {
+			var flagValue string
+			if value := flagVals[source.sourceFlag().name]; value != nil {
+				flagValue = *value
+			}
+			source.locate(flagValue, l.getenv)
+		}
+	}
+
+	var warnMu sync.Mutex
+	ctx = context.WithValue(ctx, warnKey{}, func(warning string, attrs ...any) {
+		warnMu.Lock()
+		defer warnMu.Unlock()
+		l.warn(warning, attrs...)
+	})
+
+	keys := sortedKeys(l.options())
+	jsonConfigs = make([]map[string]string, len(jsonFiles))
+	values = make([]map[string]string, len(l.Sources))
+	origins = make([]map[string]string, len(l.Sources))
+	errs := make([]error, len(jsonFiles)+len(l.Sources))
+
+	var wg sync.WaitGroup
+	for i := range jsonFiles {
+		wg.Go(func() {
+			err := ctx.Err()
+			if err == nil {
+				jsonConfigs[i], err = parseJSON(&jsonFiles[i])
+			}
+			if err != nil {
+				errs[i] = sourceError(OriginJSON+jsonFiles[i], err)
+			}
+		})
+	}
+	for i, source := range l.Sources {
+		wg.Go(func() {
+			err := ctx.Err()
+			if err == nil {
+				values[i], origins[i], err = source.Fetch(ctx, keys)
+			}
+			if err != nil {
+				errs[len(jsonFiles)+i] = sourceError(sourceName(source), err)
+			}
+		})
+	}
+	wg.Wait()
+
+	var failed sourceErrors
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return jsonConfigs, values, origins, nil
+	case 1:
+		return nil, nil, nil, failed[0]
+	default:
+		return nil, nil, nil, failed
+	}
+}
+
+// warnKey is the context key of the function reporting warnings of the
+// built-in Sources while fetching, such as a cache file that cannot be
+// written.
+type warnKey struct{}
+
+// sourceWarn returns the function reporting warnings of Sources fetched
+// with ctx, or a function ignoring them if there is none.
+func sourceWarn(ctx context.Context) func(warning string, attrs ...any) {
+	if warn, ok := ctx.Value(warnKey{}).(func(warning string, attrs ...any)); ok {
+		return warn
+	}
+	return func(string, ...any) {}
+}
+
+// sourceErrors are the errors of several sources, in order of precedence.
+type sourceErrors []error
+
+// Error returns the error messages separated by semicolons (;).
+func (e sourceErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors.
+func (e sourceErrors) Unwrap() []error {
+	return e
+}
+
+// configureSource adds values fetched from the named Source and their
+// origins against keys if not already present. It logs the number of values
+// found and the values they override.
+func (l MultiLoader) configureSource(
+	ctx context.Context,
+	config map[string]string,
+	origin map[string]string,
+	name string,
+	values map[string]string,
+	origins map[string]string,
+) {
+	found := 0
+	for _, key := range sortedKeys(l.options()) {
+		if values[key] == "" {
+			continue
+		}
+		found++
+		if config[key] == "" {
+			config[key] = values[key]
+			origin[key] = origins[key]
+		} else {
+			l.logOverride(ctx, key, origin[key], origins[key])
+		}
+	}
+	l.logSource(ctx, name, found)
+}
+
+// sourceName returns the name of a Source given by its String method, or
+// else its type.
+func sourceName(source Source) string {
+	if stringer, ok := source.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", source)
+}
+
+// sourceError describes an error reading the named source, telling apart
+// a source that timed out.
+func sourceError(name string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out reading %s: %w", name, err)
+	}
+	return fmt.Errorf("error reading %s: %w", name, err)
+}
@@ -0,0 +1,258 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _ ContextLoader = MultiLoader{}
+
+// staticSource is a Source holding fixed values, with origin "Static:<name>".
+type staticSource struct {
+	name   string
+	values map[string]string
+}
+
+func (s staticSource) Fetch(_ context.Context, keys []string) (map[string]string, map[string]string, error) {
+	values := make(map[string]string)
+	origin := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := s.values[key]; ok {
+			values[key] = value
+			origin[key] = "Static:" + s.name
+		}
+	}
+	return values, origin, nil
+}
+
+// blockingSource is a Source that waits until its context is done.
+type blockingSource struct{}
+
+func (blockingSource) Fetch(ctx context.Context, _ []string) (map[string]string, map[string]string, error) {
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+// failingSource is a Source that fails with err.
+type failingSource struct{ err error }
+
+func (s failingSource) Fetch(context.Context, []string) (map[string]string, map[string]string, error) {
+	return nil, nil, s.err
+}
+
+// slowSource is a Source that takes delay to provide its values.
+type slowSource struct {
+	delay  time.Duration
+	values map[string]string
+}
+
+func (s slowSource) Fetch(ctx context.Context, keys []string) (map[string]string, map[string]string, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	return staticSource{name: "slow", values: s.values}.Fetch(ctx, keys)
+}
+
+// barrierSource is a Source that reports its fetch on arrived and then
+// provides its values once release is closed.
+type barrierSource struct {
+	arrived chan<- struct{}
+	release <-chan struct{}
+	values  map[string]string
+}
+
+func (s barrierSource) Fetch(ctx context.Context, keys []string) (map[string]string, map[string]string, error) {
+	s.arrived <- struct{}{}
+	<-s.release
+	return staticSource{name: "barrier", values: s.values}.Fetch(ctx, keys)
+}
+
+func TestLoadFromSourcesInOrder(t *testing.T) {
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}, "opt": Option{}, "env": Option{}},
+		Sources: []Source{
+			staticSource{name: "first", values: map[string]string{"man": "man:first"}},
+			staticSource{name: "second", values: map[string]string{"man": "man:second", "opt": "opt:second"}},
+		},
+		Environ: func() []string { return []string{"opt=opt:env", "env=env:env"} },
+	}
+
+	config, origin, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from sources: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:first", "opt": "opt:second", "env": "env:env"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from sources")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	expectedOrigin := map[string]string{"man": "Static:first", "opt": "Static:second", "env": envOrig}
+	if !reflect.DeepEqual(origin, expectedOrigin) {
+		t.Error("Origins don't match when loaded from sources")
+		t.Errorf("\nActual  : %#v", origin)
+		t.Errorf("\nExpected: %#v", expectedOrigin)
+	}
+}
+
+func TestLoadContextCancelledBeforeJSONFile(t *testing.T) {
+	jsonFile := createFile(t, `{ "man": "man:json" }`)
+	defer os.Remove(jsonFile)
+
+	loader := &MultiLoader{Options: map[string]Option{"man": Option{}}, JSONKey: "conf"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	config, origin, err := loader.loadContext(ctx, []string{"-conf", jsonFile}, sampleFlagsHandler)
+	expectedMessage := "conf.Load: error reading JSON:" + jsonFile + ": context canceled"
+	if err == nil || err.Error() != expectedMessage {
+		t.Errorf("Unexpected error: %v, expected: %q", err, expectedMessage)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+func TestLoadContextDeadlineNamesSource(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		source   Source
+		expected string
+	}{
+		{&HTTPSource{URL: server.URL}, "conf.Load: timed out reading HTTP:" + server.URL + ": "},
+		{blockingSource{}, "conf.Load: timed out reading conf.blockingSource: context deadline exceeded"},
+	}
+	for _, test := range tests {
+		loader := &MultiLoader{
+			Options: map[string]Option{"man": Option{}},
+			Sources: []Source{staticSource{name: "fast"}, test.source},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+		config, origin, err := loader.loadContext(ctx, nil, sampleFlagsHandler)
+		cancel()
+		if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+			t.Errorf("Unexpected error: %v, expected prefix: %q", err, test.expected)
+		}
+
+		if config != nil || origin != nil {
+			t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+		}
+	}
+}
+
+func TestLoadFromSourcesFetchedTogether(t *testing.T) {
+	arrived := make(chan struct{}, 3)
+	release := make(chan struct{})
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}, "opt": Option{}},
+		Sources: []Source{
+			barrierSource{arrived, release, map[string]string{"man": "man:first"}},
+			barrierSource{arrived, release, map[string]string{"man": "man:second", "opt": "opt:second"}},
+			barrierSource{arrived, release, map[string]string{"opt": "opt:third"}},
+		},
+	}
+
+	go func() {
+		defer close(release)
+		for range 3 {
+			select {
+			case <-arrived:
+			case <-time.After(10 * time.Second):
+				t.Error("Sources were not fetched together")
+				return
+			}
+		}
+	}()
+
+	config, _, err := loader.load(nil, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations from sources fetched together: %s", err)
+	}
+
+	expectedConfig := map[string]string{"man": "man:first", "opt": "opt:second"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded from sources fetched together")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+}
+
+func TestLoadWithFailingSourcesError(t *testing.T) {
+	missingFile := filepath.Join(t.TempDir(), "missing.json")
+	loader := &MultiLoader{
+		Options: map[string]Option{"man": Option{}},
+		JSONKey: "conf",
+		Sources: []Source{
+			failingSource{errors.New("first broken")},
+			staticSource{name: "working", values: map[string]string{"man": "man:static"}},
+			failingSource{errors.New("second broken")},
+		},
+	}
+
+	config, origin, err := loader.load([]string{"-conf", missingFile}, sampleFlagsHandler)
+	if err == nil {
+		t.Fatal("Expected error loading configurations from failing sources")
+	}
+
+	expectedMessage := "conf.Load: error reading JSON:" + missingFile + ": open " + missingFile + ": no such file or directory; " +
+		"error reading conf.failingSource: first broken; " +
+		"error reading conf.failingSource: second broken"
+	if err.Error() != expectedMessage {
+		t.Errorf("Unexpected error message: %q, expected: %q", err, expectedMessage)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected error to wrap the missing file error: %v", err)
+	}
+
+	if config != nil || origin != nil {
+		t.Errorf("Expected empty configurations and origins, found: %#v and %#v", config, origin)
+	}
+}
+
+// BenchmarkLoadFromSlowSources loads from sources that each take 1ms. As
+// sources are fetched together, the time per load stays close to 1ms as
+// sources are added, where fetching one after another would take 1ms for
+// each source.
+func BenchmarkLoadFromSlowSources(b *testing.B) {
+	for _, count := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("sources=%d", count), func(b *testing.B) {
+			sources := make([]Source, count)
+			for i := range sources {
+				sources[i] = slowSource{delay: time.Millisecond, values: map[string]string{"man": fmt.Sprint(i)}}
+			}
+			loader := &MultiLoader{Options: map[string]Option{"man": Option{}}, Sources: sources}
+
+			for b.Loop() {
+				if _, _, err := loader.load(nil, sampleFlagsHandler); err != nil {
+					b.Fatalf("Unexpected error loading configurations from slow sources: %s", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// A Strictness decides how Load treats configuration it does not know,
+// such as keys in a JSON file that are not options.
+type Strictness int
+
+const (
+	// IgnoreUnknown silently ignores unknown configuration.
+	IgnoreUnknown Strictness = iota
+
+	// WarnUnknown reports unknown configuration through Warn, and
+	// continues loading.
+	WarnUnknown
+
+	// RejectUnknown fails Load if there is unknown configuration.
+	RejectUnknown
+)
+
+// unknownFileKeys returns a description for each key in the JSON
+// configurations that is not a configuration key, along with the file it
+// was found in and the nearest configuration key, if any. The descriptions
+// are sorted.
+func (l MultiLoader) unknownFileKeys(jsonFiles []string, jsonConfigs []map[string]string) []string {
+	options := l.options()
+	names := sortedKeys(options)
+
+	var unknown []string
+	for i, jsonConfig := range jsonConfigs {
+		for key := range jsonConfig {
+			if _, ok := options[key]; ok {
+				continue
+			}
+			unknown = append(unknown, fmt.Sprintf("%s in %s%s", key, jsonFiles[i], suggestion(key, names)))
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// reportUnknown handles unknown configuration of the given kind according
+// to strictness. It returns an error for RejectUnknown and calls warn with
+// each description for WarnUnknown.
+func (l MultiLoader) reportUnknown(strictness Strictness, kind string, unknown []string) error {
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	switch strictness {
+	case WarnUnknown:
+		for _, description := range unknown {
+			l.warn(fmt.Sprintf("unknown %s: %s", kind, description))
+		}
+	case RejectUnknown:
+		return fmt.Errorf("unknown %s: %s", kind, strings.Join(unknown, "; "))
+	}
+
+	return nil
+}
+
+// warn reports a warning through Warn, or else prints it to os.Stderr if
+// Logger is nil. The warning is also logged, along with attrs.
+func (l MultiLoader) warn(warning string, attrs ...any) {
+	l.log(context.Background(), slog.LevelWarn, "configuration warning", append([]any{"warning", warning}, attrs...)...)
+
+	switch {
+	case l.Warn != nil:
+		l.Warn(warning)
+	case l.Logger == nil:
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
+
+// suggestion returns a hint naming the candidate nearest to name by edit
+// distance, or an empty string if no candidate is near enough. A candidate
+// is near enough if at most a third of name, and no less than two
+// characters, needs editing.
+func suggestion(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if bestDistance == -1 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting
+// insertions, deletions and substitutions of runes.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
@@ -0,0 +1,133 @@
+package conf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLoadWithUnknownFileKeysIgnored(t *testing.T) {
+	jsonFile := createFile(t, `{ "prot": "8080", "host": "localhost" }`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	var warnings []string
+	options := map[string]Option{"port": Option{Default: "80"}, "host": Option{}}
+	loader := &MultiLoader{
+		Options: options,
+		JSONKey: "conf",
+		Warn:    func(warning string) { warnings = append(warnings, warning) },
+	}
+
+	config, _, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with ignored unknown keys: %s", err)
+	}
+
+	expectedConfig := map[string]string{"port": "80", "host": "localhost"}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with ignored unknown keys")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings for ignored unknown keys: %#v", warnings)
+	}
+}
+
+func TestLoadWithUnknownFileKeysWarned(t *testing.T) {
+	jsonFile := createFile(t, `{ "prot": "8080", "host": "localhost", "zzzzzz": "1" }`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	var warnings []string
+	options := map[string]Option{"port": Option{Default: "80"}, "host": Option{}}
+	loader := &MultiLoader{
+		Options:         options,
+		JSONKey:         "conf",
+		UnknownFileKeys: WarnUnknown,
+		Warn:            func(warning string) { warnings = append(warnings, warning) },
+	}
+
+	config, _, err := loader.load([]string{"-conf", jsonFile}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading configurations with warned unknown keys: %s", err)
+	}
+
+	if config["port"] != "80" {
+		t.Errorf("Unexpected configuration with warned unknown keys: %#v", config)
+	}
+
+	expectedWarnings := []string{
+		"unknown keys in JSON files: prot in " + jsonFile + " (did you mean port?)",
+		"unknown keys in JSON files: zzzzzz in " + jsonFile,
+	}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Error("Warnings don't match for unknown keys")
+		t.Errorf("\nActual  : %#v", warnings)
+		t.Errorf("\nExpected: %#v", expectedWarnings)
+	}
+}
+
+func TestLoadWithUnknownFileKeysRejectedError(t *testing.T) {
+	baseFile := createFile(t, `{ "db": { "hots": "localhost" } }`)
+	overlayFile := createFile(t, `{ "prot": "8080" }`)
+	defer func() {
+		for _, file := range []string{baseFile, overlayFile} {
+			if err := os.Remove(file); err != nil {
+				t.Fatalf("Unexpected error deleting temporary file: %s", err)
+			}
+		}
+	}()
+
+	loader := &MultiLoader{
+		Options:         map[string]Option{"port": Option{}},
+		Groups:          []Group{{Name: "db", Options: map[string]Option{"host": Option{}}}},
+		JSONKey:         "conf",
+		UnknownFileKeys: RejectUnknown,
+	}
+
+	config, origin, err := loader.load([]string{"-conf", baseFile, "-conf", overlayFile}, sampleFlagsHandler)
+	expectedMsg := "conf.Load: unknown keys in JSON files: " +
+		"db.hots in " + baseFile + " (did you mean db.host?); " +
+		"prot in " + overlayFile + " (did you mean port?)"
+	if err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for unknown keys")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for unknown keys")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"port", "port", 0},
+		{"prot", "port", 2},
+		{"hsot", "host", 2},
+		{"port", "ports", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, c := range cases {
+		if distance := editDistance(c.a, c.b); distance != c.expected {
+			t.Errorf("Invalid edit distance between %q and %q: %d, expected: %d", c.a, c.b, distance, c.expected)
+		}
+	}
+}
@@ -5,31 +5,46 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 )
 
 // parseJSON parses a JSON file with the given name into a map of key-value
-// strings. It fail if the keys are not strings or the file has more than
-// one level of nesting.
+// strings. Nested objects are flattened, joining their keys with a dot (.),
+// so that {"db": {"host": "..."}} is read as "db.host". A null value is
+// read as an empty string. It fails if the values are not strings, null or
+// objects. Errors reading the file are left to the caller to describe.
 func parseJSON(file *string) (map[string]string, error) {
-	var config map[string]string
-
 	if file == nil || *file == "" {
 		return nil, nil
 	}
 
 	content, err := os.ReadFile(*file)
 	if err != nil {
-		return nil, fmt.Errorf("error reading JSON file: %w", err)
+		return nil, err
+	}
+
+	return decodeJSON(content)
+}
+
+// decodeJSON decodes JSON content into a map of key-value strings as in
+// parseJSON. Errors do not mention where the content was read from, which
+// is left to the caller.
+func decodeJSON(content []byte) (map[string]string, error) {
+	var config map[string]string
+	err := json.Unmarshal(content, &config)
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Value == "object" {
+		config, err = parseNestedJSON(content)
 	}
 
-	err = json.Unmarshal(content, &config)
 	if err != nil {
 		var syntaxErr *json.SyntaxError
 		if errors.As(err, &syntaxErr) {
 			return nil, fmt.Errorf("json: syntax error at offset %d: %w", syntaxErr.Offset, err)
 		}
 
-		var typeErr *json.UnmarshalTypeError
 		if errors.As(err, &typeErr) {
 			return nil, fmt.Errorf("json: type error at offset %d: %w", typeErr.Offset, err)
 		}
@@ -39,3 +54,90 @@ func parseJSON(file *string) (map[string]string, error) {
 
 	return config, nil
 }
+
+// A fileList is a command-line flag value holding a file name for each
+// time the flag is given.
+type fileList []string
+
+// String returns the file names separated by commas.
+func (f *fileList) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+// Set adds a file name to the list.
+func (f *fileList) Set(name string) error {
+	*f = append(*f, name)
+	return nil
+}
+
+// parseNestedJSON parses JSON content holding nested objects into a map
+// of key-value strings, joining the keys of nested objects with a dot (.).
+func parseNestedJSON(content []byte) (map[string]string, error) {
+	var tree map[string]any
+	if err := json.Unmarshal(content, &tree); err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]string)
+	if err := flatten(config, "", tree); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// flatten adds the string values of tree to config, with their keys
+// prefixed by prefix. A null value is added as an empty string, as when
+// there are no nested objects. Nested objects are flattened recursively.
+// Keys are processed in sorted order so that errors are reported
+// consistently.
+func flatten(config map[string]string, prefix string, tree map[string]any) error {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := prefix + key
+		switch value := tree[key].(type) {
+		case string:
+			if _, ok := config[name]; ok {
+				return fmt.Errorf("key is repeated: %s", name)
+			}
+			config[name] = value
+		case nil:
+			if _, ok := config[name]; ok {
+				return fmt.Errorf("key is repeated: %s", name)
+			}
+			config[name] = ""
+		case map[string]any:
+			if err := flatten(config, name+".", value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("type error at key %s: expected string or object, found %s", name, jsonKind(value))
+		}
+	}
+
+	return nil
+}
+
+// jsonKind returns the JSON type name of a decoded JSON value.
+func jsonKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -54,7 +54,7 @@ func TestParseJSONWithNonExistingFileName(t *testing.T) {
 	name := "does-not-exist"
 	data, err := parseJSON(&name)
 
-	if expectedMsg := "error reading JSON file: "; !strings.Contains(err.Error(), expectedMsg) {
+	if expectedMsg := "open does-not-exist: "; !strings.Contains(err.Error(), expectedMsg) {
 		t.Error("Invalid error when parsing missing JSON file")
 		t.Errorf("\tActual:        %q", err)
 		t.Errorf("\tExpected part: %q", expectedMsg)
@@ -106,3 +106,88 @@ func TestParseJSONWithNonStringJSONValues(t *testing.T) {
 		t.Errorf("Unexpected data for JSON file having non-string values: %#v", data)
 	}
 }
+
+func TestParseJSONWithNestedObjects(t *testing.T) {
+	jsonFile := createFile(t, `{ "foo": "abc", "db": { "host": "localhost", "pool": { "size": "10" } } }`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	data, err := parseJSON(&jsonFile)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing JSON file with nested objects: %s", err)
+	}
+
+	expectedData := map[string]string{
+		"foo":          "abc",
+		"db.host":      "localhost",
+		"db.pool.size": "10",
+	}
+	if !reflect.DeepEqual(data, expectedData) {
+		t.Error("Invalid parsed data")
+		t.Errorf("Actual:   %#v", data)
+		t.Errorf("Expected: %#v", expectedData)
+	}
+}
+
+func TestParseJSONWithNullValues(t *testing.T) {
+	for _, content := range []string{`{"man": null}`, `{"man": null, "db": {"host": null}}`} {
+		data, err := decodeJSON([]byte(content))
+		if err != nil {
+			t.Fatalf("Unexpected error parsing JSON with null values %s: %s", content, err)
+		}
+
+		for key, value := range data {
+			if value != "" {
+				t.Errorf("Unexpected value for null key %s in %s: %q", key, content, value)
+			}
+		}
+		if _, ok := data["man"]; !ok {
+			t.Errorf("Missing null key man in %s: %#v", content, data)
+		}
+	}
+}
+
+func TestParseJSONWithNonStringNestedJSONValues(t *testing.T) {
+	jsonFile := createFile(t, `{"db": {"port": 5432}}`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	data, err := parseJSON(&jsonFile)
+
+	if expectedMsg := "json: type error at key db.port: expected string or object, found number"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error when parsing a file with JSON having non-string nested values")
+		t.Errorf("\tActual:   %q", err)
+		t.Errorf("\tExpected: %q", expectedMsg)
+	}
+
+	if len(data) != 0 {
+		t.Errorf("Unexpected data for JSON file having non-string nested values: %#v", data)
+	}
+}
+
+func TestParseJSONWithRepeatedNestedKey(t *testing.T) {
+	jsonFile := createFile(t, `{"db.host": "a", "db": {"host": "b"}}`)
+	defer func() {
+		if err := os.Remove(jsonFile); err != nil {
+			t.Fatalf("Unexpected error deleting temporary file: %s", err)
+		}
+	}()
+
+	data, err := parseJSON(&jsonFile)
+
+	if expectedMsg := "json: key is repeated: db.host"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error when parsing a file with JSON having a repeated nested key")
+		t.Errorf("\tActual:   %q", err)
+		t.Errorf("\tExpected: %q", expectedMsg)
+	}
+
+	if len(data) != 0 {
+		t.Errorf("Unexpected data for JSON file having a repeated nested key: %#v", data)
+	}
+}
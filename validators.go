@@ -0,0 +1,118 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of values accepted by an Option, as recorded by its validators.
+const (
+	kindInteger = "integer"
+	kindURL     = "url"
+)
+
+// OneOf returns a copy of the option that accepts only one of the given
+// values. It is applied in addition to an existing Validate.
+func (o Option) OneOf(values ...string) Option {
+	allowed := append([]string(nil), values...)
+	o.enum = allowed
+	return o.chain(func(value string) error {
+		for _, v := range allowed {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	})
+}
+
+// Range returns a copy of the option that accepts only integers between
+// low and high, both inclusive. It is applied in addition to an existing
+// Validate.
+func (o Option) Range(low, high int) Option {
+	o.kind = kindInteger
+	return o.chain(func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		if n < low || n > high {
+			return fmt.Errorf("must be between %d and %d", low, high)
+		}
+		return nil
+	})
+}
+
+// URL returns a copy of the option that accepts only absolute URLs. It is
+// applied in addition to an existing Validate.
+func (o Option) URL() Option {
+	o.kind = kindURL
+	return o.chain(func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("must be a URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return errors.New("must be an absolute URL")
+		}
+		return nil
+	})
+}
+
+// Pattern returns a copy of the option that accepts only values matching
+// the regular expression. The expression is anchored, so it must match the
+// whole value. It panics if the expression does not compile. It is applied
+// in addition to an existing Validate.
+func (o Option) Pattern(expr string) Option {
+	anchored := "^(?:" + expr + ")$"
+	re := regexp.MustCompile(anchored)
+	o.pattern = anchored
+	return o.chain(func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("must match %s", expr)
+		}
+		return nil
+	})
+}
+
+// chain returns a copy of the option that runs validate after the existing
+// Validate, if any.
+func (o Option) chain(validate func(string) error) Option {
+	previous := o.Validate
+	o.Validate = func(value string) error {
+		if previous != nil {
+			if err := previous(value); err != nil {
+				return err
+			}
+		}
+		return validate(value)
+	}
+	return o
+}
+
+// verifyValid returns an error if one or more configured values are
+// rejected by the Validate of their option. Empty values are not validated.
+// The error message reports every invalid key along with its origin.
+func (l MultiLoader) verifyValid(config map[string]string, origin map[string]string) error {
+	var invalid []string
+	for name, option := range l.options() {
+		if option.Validate == nil || config[name] == "" {
+			continue
+		}
+		if err := option.Validate(config[name]); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s (%s): %s", name, origin[name], err))
+		}
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("invalid configurations: %s", strings.Join(invalid, "; "))
+	}
+
+	return nil
+}
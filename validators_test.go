@@ -0,0 +1,128 @@
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithValidValues(t *testing.T) {
+	t.Setenv("mode", "prod")
+
+	options := map[string]Option{
+		"port": Option{}.Range(1, 65535),
+		"mode": Option{}.OneOf("dev", "staging", "prod"),
+		"url":  Option{Default: "https://example.com/api"}.URL(),
+		"name": Option{}.Pattern("[a-z]+"),
+		"opt":  Option{}.Range(1, 10),
+	}
+	loader := &MultiLoader{Options: options}
+
+	config, _, err := loader.load([]string{"-port", "8080", "-name", "abc"}, sampleFlagsHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error loading valid configurations: %s", err)
+	}
+
+	expectedConfig := map[string]string{
+		"port": "8080",
+		"mode": "prod",
+		"url":  "https://example.com/api",
+		"name": "abc",
+		"opt":  "",
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Error("Configurations don't match when loaded with validators")
+		t.Errorf("\nActual  : %#v", config)
+		t.Errorf("\nExpected: %#v", expectedConfig)
+	}
+}
+
+func TestLoadWithInvalidValuesError(t *testing.T) {
+	t.Setenv("mode", "qa")
+
+	options := map[string]Option{
+		"port": Option{}.Range(1, 65535),
+		"mode": Option{}.OneOf("dev", "staging", "prod"),
+		"url":  Option{Default: "example.com"}.URL(),
+		"name": Option{}.Pattern("[a-z]+"),
+		"ok":   Option{Default: "5"}.Range(1, 10),
+	}
+	loader := &MultiLoader{Options: options}
+
+	config, origin, err := loader.load([]string{"-port", "0", "-name", "abc1"}, sampleFlagsHandler)
+	expectedMsg := "conf.Load: invalid configurations: " +
+		"mode (Environment): must be one of dev, staging, prod; " +
+		"name (Flags): must match [a-z]+; " +
+		"port (Flags): must be between 1 and 65535; " +
+		"url (Defaults): must be an absolute URL"
+	if err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for invalid configurations")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+
+	if len(config) != 0 || len(origin) != 0 {
+		t.Error("Unexpected invalid values for invalid configurations")
+		t.Errorf("Config: %#v", config)
+		t.Errorf("Origin: %#v", origin)
+	}
+}
+
+func TestLoadWithCustomValidator(t *testing.T) {
+	options := map[string]Option{
+		"even": Option{Validate: func(value string) error {
+			if len(value)%2 != 0 {
+				return errors.New("must have even length")
+			}
+			return nil
+		}},
+	}
+	loader := &MultiLoader{Options: options}
+
+	_, _, err := loader.load([]string{"-even", "abc"}, sampleFlagsHandler)
+	if expectedMsg := "conf.Load: invalid configurations: even (Flags): must have even length"; err == nil || err.Error() != expectedMsg {
+		t.Error("Invalid error message for value rejected by custom validator")
+		t.Errorf("Actual  : %q", err)
+		t.Errorf("Expected: %q", expectedMsg)
+	}
+}
+
+func TestValidatorsChain(t *testing.T) {
+	option := Option{Validate: func(value string) error {
+		if strings.HasPrefix(value, "9") {
+			return errors.New("must not start with 9")
+		}
+		return nil
+	}}.Range(1, 100).OneOf("10", "20", "95")
+
+	cases := map[string]string{
+		"10":  "",
+		"95":  "must not start with 9",
+		"30":  "must be one of 10, 20, 95",
+		"200": "must be between 1 and 100",
+		"x":   "must be an integer",
+	}
+	for value, expectedMsg := range cases {
+		err := option.Validate(value)
+		if expectedMsg == "" && err != nil {
+			t.Errorf("Unexpected error validating %q: %s", value, err)
+		}
+		if expectedMsg != "" && (err == nil || err.Error() != expectedMsg) {
+			t.Errorf("Invalid error validating %q", value)
+			t.Errorf("Actual  : %q", err)
+			t.Errorf("Expected: %q", expectedMsg)
+		}
+	}
+}
+
+func TestPatternIsAnchored(t *testing.T) {
+	option := Option{}.Pattern("a|b")
+
+	if err := option.Validate("b"); err != nil {
+		t.Errorf("Unexpected error for value matching pattern: %s", err)
+	}
+	if err := option.Validate("ab"); err == nil {
+		t.Error("Unexpected success for value partly matching pattern")
+	}
+}